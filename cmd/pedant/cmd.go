@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/qx66/pedant/internal/biz"
@@ -10,7 +12,12 @@ import (
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var configPath string
@@ -20,20 +27,33 @@ func init() {
 }
 
 type app struct {
+	authUseCase       *biz.AuthUseCase
 	sessionUseCase    *biz.SessionUseCase
+	personaUseCase    *biz.PersonaUseCase
 	multiModalUseCase *biz.MultiModalUseCase
 	imageUseCase      *biz.ImageUseCase
+	usageUseCase      *biz.UsageUseCase
+	searchUseCase     *biz.SearchUseCase
 }
 
-func newApp(sessionUseCase *biz.SessionUseCase, multiModalUseCase *biz.MultiModalUseCase, imageUseCase *biz.ImageUseCase) *app {
+func newApp(authUseCase *biz.AuthUseCase, sessionUseCase *biz.SessionUseCase, personaUseCase *biz.PersonaUseCase, multiModalUseCase *biz.MultiModalUseCase, imageUseCase *biz.ImageUseCase, usageUseCase *biz.UsageUseCase, searchUseCase *biz.SearchUseCase) *app {
 	return &app{
+		authUseCase:       authUseCase,
 		sessionUseCase:    sessionUseCase,
+		personaUseCase:    personaUseCase,
 		multiModalUseCase: multiModalUseCase,
 		imageUseCase:      imageUseCase,
+		usageUseCase:      usageUseCase,
+		searchUseCase:     searchUseCase,
 	}
 }
 
 func main() {
+	// pedant import ...
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(importCommand(os.Args[2:]))
+	}
+	
 	flag.Parse()
 	
 	logger, err := zap.NewProduction()
@@ -43,22 +63,120 @@ func main() {
 	}
 	
 	//
+	bootstrap, ok := loadBootstrap(logger, configPath)
+	if !ok {
+		return
+	}
+	
+	iApp, clean, err := initApp(bootstrap.Data, bootstrap.Pedant, bootstrap.Llm, logger)
+	defer clean()
+	if err != nil {
+		logger.Error("初始化程序失败", zap.Error(err))
+	}
+	
+	route := gin.New()
+	
+	// 所有接口都需要 Authorization: Bearer <token>，按 token 的权限分组
+	chatRoute := route.Group("", iApp.authUseCase.Require(biz.ScopeChat))
+	imageRoute := route.Group("", iApp.authUseCase.Require(biz.ScopeImage))
+	multiModalRoute := route.Group("", iApp.authUseCase.Require(biz.ScopeMultiModal))
+	adminRoute := route.Group("", iApp.authUseCase.Require(biz.ScopeAdmin))
+	
+	// session
+	chatRoute.GET("/chat/session", iApp.sessionUseCase.ListSession)
+	chatRoute.POST("/chat/session", iApp.sessionUseCase.CreateSession)
+	chatRoute.PUT("/chat/session", iApp.sessionUseCase.UpdateSession)
+	chatRoute.DELETE("/chat/session", iApp.sessionUseCase.DelSession)
+	chatRoute.POST("/chat/session/rename", iApp.sessionUseCase.RenameSession)
+	chatRoute.POST("/chat/session/archive", iApp.sessionUseCase.ArchiveSession)
+	chatRoute.POST("/chat/session/unarchive", iApp.sessionUseCase.UnarchiveSession)
+	chatRoute.POST("/chat/session/pin", iApp.sessionUseCase.PinSession)
+	chatRoute.POST("/chat/session/unpin", iApp.sessionUseCase.UnpinSession)
+	chatRoute.GET("/chat/session/export", iApp.sessionUseCase.ExportSession)
+	chatRoute.GET("/chat/session/export/all", iApp.sessionUseCase.ExportAllSession)
+	chatRoute.POST("/chat/session/import", iApp.sessionUseCase.ImportSession)
+	chatRoute.POST("/chat/session/fork", iApp.sessionUseCase.ForkSession)
+	chatRoute.GET("/chat/session/tree", iApp.sessionUseCase.SessionTree)
+	
+	// session context
+	chatRoute.GET("/chat/session/context", iApp.sessionUseCase.ListSessionContext)
+	chatRoute.POST("/chat/session/context", iApp.sessionUseCase.CreateSessionContext)
+	chatRoute.POST("/chat/session/context/regenerate", iApp.sessionUseCase.RegenerateSessionContext)
+	chatRoute.POST("/chat/session/context/edit", iApp.sessionUseCase.EditSessionContext)
+	chatRoute.GET("/chat/session/context/versions", iApp.sessionUseCase.ListContextVersion)
+	chatRoute.GET("/chat/ws", iApp.sessionUseCase.ChatWebSocket)
+	
+	// persona
+	chatRoute.GET("/persona", iApp.personaUseCase.ListPersona)
+	chatRoute.POST("/persona", iApp.personaUseCase.CreatePersona)
+	chatRoute.PUT("/persona", iApp.personaUseCase.UpdatePersona)
+	chatRoute.DELETE("/persona", iApp.personaUseCase.DelPersona)
+	
+	// search
+	chatRoute.GET("/search", iApp.searchUseCase.Search)
+	
+	//
+	imageRoute.GET("/image", iApp.imageUseCase.Get)
+	imageRoute.POST("/image", iApp.imageUseCase.Create)
+	
+	//
+	multiModalRoute.GET("/multiModal", iApp.multiModalUseCase.Get)
+	multiModalRoute.POST("/multiModal", iApp.multiModalUseCase.Create)
+	
+	// usage
+	adminRoute.GET("/usage", iApp.usageUseCase.Report)
+	
+	// 收到退出信号时取消所有请求的 context，中止正在进行的上游大模型请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	server := &http.Server{
+		Addr:    ":20000",
+		Handler: route,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("启动程序失败", zap.Error(err))
+			stop()
+		}
+	}()
+	
+	<-ctx.Done()
+	
+	// 等待请求保存已经生成的内容后退出
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("关闭程序失败", zap.Error(err))
+	}
+}
+
+// 加载配置文件，失败时记录日志
+
+func loadBootstrap(logger *zap.Logger, path string) (*conf.Bootstrap, bool) {
 	//
-	if configPath == "" {
+	if path == "" {
 		logger.Error("configPath 参数为空")
-		return
+		return nil, false
 	}
 	
 	//
-	f, err := os.Open(configPath)
+	f, err := os.Open(path)
 	defer f.Close()
 	if err != nil {
 		logger.Error(
 			"加载配置文件失败",
-			zap.String("configPath", configPath),
+			zap.String("configPath", path),
 			zap.Error(err),
 		)
-		return
+		return nil, false
 	}
 	
 	//
@@ -69,7 +187,7 @@ func main() {
 			"加载配置文件copy内容失败",
 			zap.Error(err),
 		)
-		return
+		return nil, false
 	}
 	
 	//
@@ -80,34 +198,8 @@ func main() {
 			"序列化配置失败",
 			zap.Error(err),
 		)
-		return
-	}
-	
-	iApp, clean, err := initApp(bootstrap.Data, bootstrap.Pedant, bootstrap.Llm, logger)
-	defer clean()
-	if err != nil {
-		logger.Error("初始化程序失败", zap.Error(err))
+		return nil, false
 	}
 	
-	route := gin.New()
-	
-	// session
-	route.GET("/chat/session", iApp.sessionUseCase.ListSession)
-	route.POST("/chat/session", iApp.sessionUseCase.CreateSession)
-	route.DELETE("/chat/session", iApp.sessionUseCase.DelSession)
-	
-	// session context
-	route.GET("/chat/session/context", iApp.sessionUseCase.ListSessionContext)
-	route.POST("/chat/session/context", iApp.sessionUseCase.CreateSessionContext)
-	
-	//
-	route.GET("/image", iApp.imageUseCase.Get)
-	route.POST("/image", iApp.imageUseCase.Create)
-	
-	//
-	route.GET("/multiModal", iApp.multiModalUseCase.Get)
-	route.POST("/multiModal", iApp.multiModalUseCase.Create)
-	
-	err = route.Run(":20000")
-	logger.Error("启动程序失败", zap.Error(err))
+	return &bootstrap, true
 }
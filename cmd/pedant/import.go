@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/qx66/pedant/internal/biz"
+	"go.uber.org/zap"
+	"os"
+)
+
+// 从 ChatGPT 导出文件或 OpenAI messages 导入会话
+// pedant import -configPath config.yaml -userUuid 1 [-format chatgpt|openai] [-llm openai] [-model gpt-4o] conversations.json ...
+
+func importCommand(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("configPath", "", "-configPath")
+	userUuid := fs.String("userUuid", "", "导入到该用户")
+	format := fs.String("format", "", "chatgpt / openai，为空时自动识别")
+	llm := fs.String("llm", "", "导入对话的大模型，为空时 ChatGPT 导出的对话使用 openai")
+	model := fs.String("model", "", "消息中没有模型时使用")
+	_ = fs.Parse(args)
+	
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Println("初始化日志失败")
+		return 1
+	}
+	
+	if *userUuid == "" || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: pedant import -configPath config.yaml -userUuid <userUuid> [-format chatgpt|openai] [-llm llm] [-model model] file ...")
+		return 2
+	}
+	
+	bootstrap, ok := loadBootstrap(logger, *configPath)
+	if !ok {
+		return 1
+	}
+	
+	iApp, clean, err := initApp(bootstrap.Data, bootstrap.Pedant, bootstrap.Llm, logger)
+	if err != nil {
+		logger.Error("初始化程序失败", zap.Error(err))
+		return 1
+	}
+	defer clean()
+	
+	opts := biz.ImportOptions{Format: *format, Llm: *llm, Model: *model}
+	code := 0
+	for _, file := range fs.Args() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			logger.Error("读取导入文件失败", zap.String("file", file), zap.Error(err))
+			code = 1
+			continue
+		}
+		
+		results, err := iApp.sessionUseCase.Import(context.Background(), *userUuid, data, opts)
+		for _, result := range results {
+			fmt.Printf("%s\t%d\t%s\n", result.Uuid, result.Turns, result.Name)
+		}
+		
+		if err != nil {
+			logger.Error("导入会话失败", zap.String("file", file), zap.Int("imported", len(results)), zap.Error(err))
+			code = 1
+		}
+	}
+	return code
+}
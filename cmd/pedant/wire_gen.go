@@ -21,13 +21,23 @@ func initApp(confData *conf.Data, pedant *conf.Pedant, llm *conf.Llm, logger *za
 		return nil, nil, err
 	}
 	sessionRepo := data.NewSessionDataSource(dataData)
+	personaRepo := data.NewPersonaDataSource(dataData)
 	localCacheRepo := data.NewLocalCacheDataSource(dataData)
-	sessionUseCase := biz.NewSessionUseCase(sessionRepo, localCacheRepo, pedant, llm, logger)
+	registry := biz.NewChatRegistry(localCacheRepo, llm, logger)
+	quotaRepo := data.NewQuotaDataSource(dataData, pedant)
+	quotaUseCase := biz.NewQuotaUseCase(quotaRepo, pedant, logger)
+	sessionUseCase := biz.NewSessionUseCase(sessionRepo, personaRepo, localCacheRepo, registry, quotaUseCase, pedant, llm, logger)
+	personaUseCase := biz.NewPersonaUseCase(personaRepo, logger)
 	multiModalRepo := data.NewMultiModalDataSource(dataData)
-	multiModalUseCase := biz.NewMultiModalUseCase(multiModalRepo, localCacheRepo, pedant, llm, logger)
+	multiModalUseCase := biz.NewMultiModalUseCase(multiModalRepo, localCacheRepo, quotaUseCase, pedant, llm, logger)
 	imageRepo := data.NewImageDataSource(dataData)
-	imageUseCase := biz.NewImageUseCase(imageRepo, localCacheRepo, pedant, llm, logger)
-	mainApp := newApp(sessionUseCase, multiModalUseCase, imageUseCase)
+	imageUseCase := biz.NewImageUseCase(imageRepo, localCacheRepo, quotaUseCase, pedant, llm, logger)
+	authUseCase := biz.NewAuthUseCase(pedant, logger)
+	usageRepo := data.NewUsageDataSource(dataData)
+	usageUseCase := biz.NewUsageUseCase(usageRepo, pedant, logger)
+	searchRepo := data.NewSearchDataSource(dataData)
+	searchUseCase := biz.NewSearchUseCase(searchRepo, logger)
+	mainApp := newApp(authUseCase, sessionUseCase, personaUseCase, multiModalUseCase, imageUseCase, usageUseCase, searchUseCase)
 	return mainApp, func() {
 		cleanup()
 	}, nil
@@ -9,10 +9,24 @@ use pedant;
 drop table if exists session;
 create table if not exists session
 (
-    uuid        varchar(50) not null primary key,
-    user_uuid   varchar(50) not null comment '用户Uuid',
-    name        text,
-    create_time bigint
+    uuid          varchar(50) not null primary key,
+    user_uuid     varchar(50) not null comment '用户Uuid',
+    name          text,
+    llm           varchar(100) comment '会话使用的大模型，为空时使用全局配置',
+    model         varchar(100) comment '会话使用的模型，为空时使用大模型默认模型',
+    system_prompt text comment '会话的系统指令',
+    persona_uuid  varchar(50) comment '引用的persona',
+    summary       text comment '超出上下文窗口的历史摘要',
+    summary_time  bigint comment '摘要包含的最后一轮对话的时间',
+    summary_uuid  varchar(50) comment '摘要包含的最后一轮对话的uuid',
+    archived      tinyint(1) not null default 0 comment '是否归档',
+    pinned        tinyint(1) not null default 0 comment '是否置顶',
+    parent_uuid         varchar(50) comment '分支的来源会话',
+    parent_context_uuid varchar(50) comment '从来源会话的该轮对话分支',
+    create_time   bigint,
+    delete_time   bigint not null default 0 comment '删除时间，0为未删除',
+    key idx_user_create_time (user_uuid, create_time, uuid),
+    key idx_parent (parent_uuid)
 ) comment 'session表';
 
 
@@ -26,11 +40,56 @@ create table if not exists session_context
     prompt_tokens     int default 0 comment '问题tokens数',
     completion_tokens int default 0 comment '回答tokens数',
     total_tokens      int default 0 comment 'tokens总数',
+    finish_reason     varchar(50) comment '停止生成的原因',
     llm               varchar(100) comment '大模型语言',
-    create_time       bigint
+    model             varchar(100) comment '模型',
+    options           text comment '采样参数 (json)',
+    version           int default 1 comment '版本，重新生成或编辑后递增',
+    source            varchar(20) not null default '' comment '来源，为空是生成的对话，import为导入的对话，fork为分支复制的对话',
+    create_time       bigint,
+    delete_time       bigint not null default 0 comment '删除时间，随会话一起删除',
+    key idx_session_create_time (session_uuid, create_time, uuid),
+    fulltext key ft_content (user_content, assistant_content) with parser ngram
 ) comment 'session上下文表';
 
 
+drop table if exists session_context_version;
+create table if not exists session_context_version
+(
+    uuid              varchar(50) not null primary key,
+    context_uuid      varchar(50) not null comment '被重新生成或编辑的对话',
+    version           int not null comment '版本',
+    position          int default 0 comment '在该版本中的顺序，0为被替换的对话',
+    source_uuid       varchar(50) comment '对话原来的uuid',
+    session_uuid      varchar(50) not null comment 'sessionUuid',
+    user_content      text comment '用户提交内容',
+    assistant_content text comment '助理返回内容',
+    prompt_tokens     int default 0 comment '问题tokens数',
+    completion_tokens int default 0 comment '回答tokens数',
+    total_tokens      int default 0 comment 'tokens总数',
+    finish_reason     varchar(50) comment '停止生成的原因',
+    llm               varchar(100) comment '大模型语言',
+    model             varchar(100) comment '模型',
+    options           text comment '采样参数 (json)',
+    source            varchar(20) not null default '' comment '来源，为空是生成的对话，import为导入的对话，fork为分支复制的对话',
+    create_time       bigint,
+    archive_time      bigint comment '被替换的时间',
+    key idx_session_context (session_uuid, context_uuid, version, position)
+) comment 'session上下文历史版本';
+
+
+drop table if exists persona;
+create table if not exists persona
+(
+    uuid          varchar(50) not null primary key,
+    user_uuid     varchar(50) not null comment '用户Uuid',
+    name          varchar(200) comment '名称',
+    system_prompt text comment '系统指令',
+    create_time   bigint,
+    update_time   bigint
+) comment 'persona表';
+
+
 drop table if exists multi_modal;
 create table if not exists multi_modal
 (
@@ -40,7 +99,14 @@ create table if not exists multi_modal
     images            longtext comment '图片base64',
     assistant_content text comment '助理返回内容',
     llm               varchar(100) comment '大模型语言',
-    create_time       bigint
+    prompt_tokens     int default 0 comment '问题tokens数',
+    completion_tokens int default 0 comment '回答tokens数',
+    total_tokens      int default 0 comment 'tokens总数',
+    finish_reason     varchar(50) comment '停止生成的原因',
+    block_reason      varchar(50) comment '被安全策略拦截的原因',
+    create_time       bigint,
+    key idx_user_create_time (user_uuid, create_time, uuid),
+    fulltext key ft_content (user_content, assistant_content) with parser ngram
 ) comment '多模态';
 
 drop table if exists image;
@@ -53,6 +119,15 @@ create table if not exists image
         images longtext,
         prompt_tokens int,
         total_tokens int,
-        create_time bigint
+        create_time bigint,
+        key idx_user_create_time (user_uuid, create_time, uuid)
 ) comment '图片';
 
+
+drop table if exists quota_counter;
+create table if not exists quota_counter
+(
+    counter_key varchar(255) not null primary key comment '限流对象 + 时间窗口',
+    value       bigint not null default 0 comment '请求次数或 tokens 数',
+    expire_time bigint comment '过期时间，过期的计数会被删除'
+) comment '限流计数 (pedant.quota.store 为 mysql 时使用)';
@@ -0,0 +1,306 @@
+package biz
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/jwt"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// 接口权限
+
+const (
+	ScopeChat       = "chat"       // 会话、上下文、WebSocket、系统指令
+	ScopeImage      = "image"      // 文生图
+	ScopeMultiModal = "multimodal" // 多模态
+	ScopeAdmin      = "admin"      // 拥有所有权限
+)
+
+// 通过认证后，token 名称保存在 gin.Context 中
+
+const AuthTokenNameKey = "authTokenName"
+
+var (
+	errUnauthenticated = errors.New("unauthenticated")
+	errUserMismatch    = errors.New("userUuid does not match the credential")
+	errUserRequired    = errors.New("userUuid is required")
+)
+
+// Identity 通过认证的调用方
+// Delegate 为 true 时可以代替任意用户调用接口 (用户由参数 userUuid 指定)，否则只能访问 UserUuid 的数据
+
+type Identity struct {
+	Name     string
+	UserUuid string
+	Delegate bool
+	scopes   map[string]bool
+}
+
+func (identity Identity) allow(scope string) bool {
+	return identity.scopes[scope] || identity.scopes[ScopeAdmin]
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// 从请求上下文中获取用户，requested 为客户端传递的 userUuid
+// 绑定了用户的凭证忽略为空的 requested，与凭证中的用户不一致时拒绝
+
+func userFromContext(ctx context.Context, requested string) (string, error) {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return "", errUnauthenticated
+	}
+	
+	if identity.Delegate {
+		if requested == "" {
+			return "", errUserRequired
+		}
+		return requested, nil
+	}
+	
+	if requested != "" && requested != identity.UserUuid {
+		return "", errUserMismatch
+	}
+	return identity.UserUuid, nil
+}
+
+// 获取失败时直接返回错误响应，与 common.JsonUnmarshal 相同
+
+func resolveUser(c *gin.Context, requested string) (string, error) {
+	userUuid, err := userFromContext(c.Request.Context(), requested)
+	switch {
+	case err == nil:
+		return userUuid, nil
+	case errors.Is(err, errUserRequired):
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+	case errors.Is(err, errUserMismatch):
+		c.JSON(403, gin.H{"errCode": errCode.UserPermissionDenyCode, "errMsg": errCode.UserPermissionDenyMsg})
+	default:
+		c.JSON(401, gin.H{"errCode": errCode.UserUnAuthorizeCode, "errMsg": errCode.UserUnAuthorizeMsg})
+	}
+	c.Abort()
+	return "", err
+}
+
+type authToken struct {
+	token    []byte
+	identity Identity
+}
+
+type AuthUseCase struct {
+	tokens    []authToken
+	verifier  *jwt.Verifier
+	userClaim string
+	jwtScopes map[string]bool
+	logger    *zap.Logger
+}
+
+// NewAuthUseCase pedant.token 兼容旧配置，拥有所有权限，pedant.tokens 可以为不同的调用方分配不同权限的 token
+// 配置了 pedant.jwt 时，也可以使用 JWT 调用接口
+// 没有配置任何凭证时无法启动，避免接口对外开放
+
+func NewAuthUseCase(pedant *conf.Pedant, logger *zap.Logger) *AuthUseCase {
+	authUseCase := &AuthUseCase{
+		logger: logger,
+	}
+	names := make(map[string]bool)
+	
+	if pedant.GetToken() != "" {
+		authUseCase.tokens = append(authUseCase.tokens, authToken{
+			token: []byte(pedant.GetToken()),
+			identity: Identity{
+				Name:     "default",
+				Delegate: true,
+				scopes:   map[string]bool{ScopeAdmin: true},
+			},
+		})
+		names["default"] = true
+	}
+	
+	for _, token := range pedant.GetTokens() {
+		if token.GetName() == "" || token.GetToken() == "" {
+			panic("pedant.tokens 配置错误，name 和 token 不能为空")
+		}
+		
+		if names[token.GetName()] {
+			panic(fmt.Sprintf("pedant.tokens 配置错误，token 名称重复: %s", token.GetName()))
+		}
+		names[token.GetName()] = true
+		
+		if (token.GetUserUuid() == "") == !token.GetDelegate() {
+			panic(fmt.Sprintf("pedant.tokens 配置错误，%s 需要配置 userUuid 或 delegate 其中之一", token.GetName()))
+		}
+		
+		authUseCase.tokens = append(authUseCase.tokens, authToken{
+			token: []byte(token.GetToken()),
+			identity: Identity{
+				Name:     token.GetName(),
+				UserUuid: token.GetUserUuid(),
+				Delegate: token.GetDelegate(),
+				scopes:   parseScopes(token.GetScopes(), "pedant.tokens"),
+			},
+		})
+	}
+	
+	if pedant.GetJwt().GetJwksFile() != "" {
+		keys, err := jwt.LoadKeySet(pedant.GetJwt().GetJwksFile())
+		if err != nil {
+			panic(fmt.Sprintf("pedant.jwt 配置错误，加载 JWKS 失败: %s", err))
+		}
+		
+		authUseCase.verifier = &jwt.Verifier{
+			Issuer:   pedant.GetJwt().GetIssuer(),
+			Audience: pedant.GetJwt().GetAudience(),
+			Keys:     keys,
+			Leeway:   time.Minute,
+		}
+		
+		authUseCase.userClaim = pedant.GetJwt().GetUserClaim()
+		if authUseCase.userClaim == "" {
+			authUseCase.userClaim = "sub"
+		}
+		
+		authUseCase.jwtScopes = parseScopes(pedant.GetJwt().GetScopes(), "pedant.jwt")
+		if len(authUseCase.jwtScopes) == 0 {
+			authUseCase.jwtScopes = map[string]bool{ScopeChat: true, ScopeImage: true, ScopeMultiModal: true}
+		}
+	}
+	
+	if len(authUseCase.tokens) == 0 && authUseCase.verifier == nil {
+		panic("未配置 pedant.token、pedant.tokens 或 pedant.jwt")
+	}
+	
+	return authUseCase
+}
+
+func parseScopes(values []string, field string) map[string]bool {
+	scopes := make(map[string]bool)
+	for _, scope := range values {
+		switch scope {
+		case ScopeChat, ScopeImage, ScopeMultiModal, ScopeAdmin:
+			scopes[scope] = true
+		default:
+			panic(fmt.Sprintf("%s 配置错误，未知的权限: %s", field, scope))
+		}
+	}
+	return scopes
+}
+
+// Require 校验请求头 Authorization: Bearer <token>，token 需要拥有 scope 或 admin 权限
+// 浏览器无法为 WebSocket 设置请求头，WebSocket 握手请求可以通过子协议 bearer.<token> 传递 token，
+// 不支持 ?token=<token>，避免 token 出现在代理和访问日志中
+// 通过认证后，调用方的身份保存在 c.Request.Context() 中
+
+func (authUseCase *AuthUseCase) Require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := authUseCase.authenticate(bearerToken(c))
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(401, gin.H{"errCode": errCode.UserUnAuthorizeCode, "errMsg": errCode.UserUnAuthorizeMsg})
+			return
+		}
+		
+		if !identity.allow(scope) {
+			authUseCase.logger.Info("token 没有权限", zap.String("token", identity.Name), zap.String("scope", scope))
+			c.AbortWithStatusJSON(403, gin.H{"errCode": errCode.UserPermissionDenyCode, "errMsg": errCode.UserPermissionDenyMsg})
+			return
+		}
+		
+		c.Set(AuthTokenNameKey, identity.Name)
+		c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+func (authUseCase *AuthUseCase) authenticate(value string) (Identity, bool) {
+	if value == "" {
+		return Identity{}, false
+	}
+	
+	for i := range authUseCase.tokens {
+		if subtle.ConstantTimeCompare(authUseCase.tokens[i].token, []byte(value)) == 1 {
+			return authUseCase.tokens[i].identity, true
+		}
+	}
+	
+	if authUseCase.verifier == nil || strings.Count(value, ".") != 2 {
+		return Identity{}, false
+	}
+	
+	claims, err := authUseCase.verifier.Verify(value)
+	if err != nil {
+		authUseCase.logger.Info("JWT 校验失败", zap.Error(err))
+		return Identity{}, false
+	}
+	
+	userUuid := claims.String(authUseCase.userClaim)
+	if userUuid == "" {
+		authUseCase.logger.Info("JWT 中没有用户", zap.String("claim", authUseCase.userClaim))
+		return Identity{}, false
+	}
+	
+	return Identity{
+		Name:     "jwt:" + claims.String("iss"),
+		UserUuid: userUuid,
+		scopes:   authUseCase.claimScopes(claims),
+	}, true
+}
+
+// JWT 的权限来自 scope (空格分隔，RFC 8693) 或 scp (数组)，忽略无法识别的权限
+
+func (authUseCase *AuthUseCase) claimScopes(claims jwt.Claims) map[string]bool {
+	values := strings.Fields(claims.String("scope"))
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, v := range scp {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	
+	scopes := make(map[string]bool)
+	for _, scope := range values {
+		switch scope {
+		case ScopeChat, ScopeImage, ScopeMultiModal, ScopeAdmin:
+			scopes[scope] = true
+		}
+	}
+	
+	if len(scopes) == 0 {
+		return authUseCase.jwtScopes
+	}
+	return scopes
+}
+
+func bearerToken(c *gin.Context) string {
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		for _, protocol := range websocket.Subprotocols(c.Request) {
+			if strings.HasPrefix(protocol, wsTokenProtocolPrefix) {
+				return strings.TrimPrefix(protocol, wsTokenProtocolPrefix)
+			}
+		}
+	}
+	return ""
+}
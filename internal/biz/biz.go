@@ -5,16 +5,21 @@ import "github.com/google/wire"
 type LocalCacheRepo interface {
 	SetLocalCache(key string, value []byte) error
 	GetLocalCache(key string) ([]byte, error)
+	DeleteLocalCache(key string) error
 }
 
-var ProviderSet = wire.NewSet(NewSessionUseCase, NewMultiModalUseCase, NewImageUseCase)
+var ProviderSet = wire.NewSet(NewAuthUseCase, NewQuotaUseCase, NewChatRegistry, NewSessionUseCase, NewPersonaUseCase, NewMultiModalUseCase, NewImageUseCase, NewUsageUseCase, NewSearchUseCase)
 
 type LLM string
 
 const (
-	GoogleLLM     = "gemini"
-	OpenAILLM     = "openai"
-	BaiduCloudLLM = "ernieBot"
+	GoogleLLM       = "gemini"
+	OpenAILLM       = "openai"
+	BaiduCloudLLM   = "ernieBot"
+	OllamaLLM       = "ollama"
+	DeepSeekLLM     = "deepseek"
+	AlibabaCloudLLM = "qwen"
+	VolcengineLLM   = "doubao"
 )
 
 const (
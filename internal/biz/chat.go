@@ -0,0 +1,224 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/alibabaCloud"
+	"github.com/qx66/pedant/pkg/baiduCloud"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/qx66/pedant/pkg/deepseek"
+	"github.com/qx66/pedant/pkg/gemini"
+	"github.com/qx66/pedant/pkg/ollama"
+	"github.com/qx66/pedant/pkg/openai"
+	"github.com/qx66/pedant/pkg/volcengine"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 只注册配置了认证信息的大模型，新增大模型厂商时在此处注册对应的适配器即可
+// 配置了多个 apikey 时使用 chat.Pool 组合，失败时按厂商的 retry 配置重试
+
+func NewChatRegistry(localCacheRepo LocalCacheRepo, llm *conf.Llm, logger *zap.Logger) *chat.Registry {
+	registry := chat.NewRegistry()
+	
+	openaiKeys := credentials(llm.GetOpenai().GetApiKey(), "", llm.GetOpenai().GetKeys())
+	if len(openaiKeys) > 0 {
+		cli := newHttpClient(llm.GetOpenai().GetProxy())
+		registry.Register(OpenAILLM, newPool(llm.GetOpenai().GetStrategy(), llm.GetOpenai().GetRetry(), openaiKeys, func(c *conf.Credential) chat.Provider {
+			return openai.NewProvider(openai.NewClient(c.ApiKey, llm.GetOpenai().GetBaseUrl(), cli))
+		}))
+	}
+	
+	geminiKeys := credentials(llm.GetGemini().GetApiKey(), "", llm.GetGemini().GetKeys())
+	if len(geminiKeys) > 0 {
+		cli := newHttpClient(llm.GetGemini().GetProxy())
+		registry.Register(GoogleLLM, newPool(llm.GetGemini().GetStrategy(), llm.GetGemini().GetRetry(), geminiKeys, func(c *conf.Credential) chat.Provider {
+			return gemini.NewProvider(gemini.NewRestClient(c.ApiKey, llm.GetGemini().GetBaseUrl(), cli))
+		}))
+	}
+	
+	qianfanKeys := credentials(llm.GetQianfan().GetApiKey(), llm.GetQianfan().GetSecretKey(), llm.GetQianfan().GetKeys())
+	if len(qianfanKeys) > 0 {
+		client := baiduCloud.NewClient(llm.GetQianfan().GetBaseUrl(), newHttpClient(llm.GetQianfan().GetProxy()))
+		registry.Register(BaiduCloudLLM, newPool(llm.GetQianfan().GetStrategy(), llm.GetQianfan().GetRetry(), qianfanKeys, func(c *conf.Credential) chat.Provider {
+			accessToken := &qianFanAccessToken{
+				client:         client,
+				localCacheRepo: localCacheRepo,
+				apiKey:         c.ApiKey,
+				secretKey:      c.SecretKey,
+				logger:         logger,
+			}
+			return baiduCloud.NewProvider(client, accessToken)
+		}))
+	}
+	
+	if llm.GetOllama().GetBaseUrl() != "" {
+		registry.Register(OllamaLLM, chat.NewRetryProvider(
+			ollama.NewProvider(ollama.NewClient(llm.Ollama.BaseUrl, newHttpClient(llm.Ollama.GetProxy())), llm.Ollama.Model),
+			retryPolicy(llm.Ollama.GetRetry()),
+		))
+	}
+	
+	deepseekKeys := credentials(llm.GetDeepseek().GetApiKey(), "", llm.GetDeepseek().GetKeys())
+	if len(deepseekKeys) > 0 {
+		cli := newHttpClient(llm.GetDeepseek().GetProxy())
+		registry.Register(DeepSeekLLM, newPool(llm.GetDeepseek().GetStrategy(), llm.GetDeepseek().GetRetry(), deepseekKeys, func(c *conf.Credential) chat.Provider {
+			return deepseek.NewProvider(deepseek.NewClient(c.ApiKey, llm.GetDeepseek().GetBaseUrl(), cli))
+		}))
+	}
+	
+	alibabaCloudKeys := credentials(llm.GetAlibabaCloud().GetApiKey(), "", llm.GetAlibabaCloud().GetKeys())
+	if len(alibabaCloudKeys) > 0 {
+		cli := newHttpClient(llm.GetAlibabaCloud().GetProxy())
+		registry.Register(AlibabaCloudLLM, newPool(llm.GetAlibabaCloud().GetStrategy(), llm.GetAlibabaCloud().GetRetry(), alibabaCloudKeys, func(c *conf.Credential) chat.Provider {
+			return alibabaCloud.NewProvider(alibabaCloud.NewClient(c.ApiKey, llm.GetAlibabaCloud().GetBaseUrl(), cli))
+		}))
+	}
+	
+	volcengineKeys := credentials(llm.GetVolcengine().GetApiKey(), "", llm.GetVolcengine().GetKeys())
+	if len(volcengineKeys) > 0 {
+		cli := newHttpClient(llm.GetVolcengine().GetProxy())
+		registry.Register(VolcengineLLM, newPool(llm.GetVolcengine().GetStrategy(), llm.GetVolcengine().GetRetry(), volcengineKeys, func(c *conf.Credential) chat.Provider {
+			return volcengine.NewProvider(volcengine.NewClient(c.ApiKey, llm.GetVolcengine().GetBaseUrl(), cli, 120), llm.Volcengine.Endpoint)
+		}))
+	}
+	
+	logger.Info("注册大模型成功", zap.Strings("llm", registry.Names()))
+	return registry
+}
+
+// 合并单个 apiKey 的旧配置和 keys 列表，跳过没有 apiKey 的配置
+
+func credentials(apiKey, secretKey string, keys []*conf.Credential) []*conf.Credential {
+	var result []*conf.Credential
+	if apiKey != "" {
+		result = append(result, &conf.Credential{ApiKey: apiKey, SecretKey: secretKey, Weight: 1})
+	}
+	
+	for _, key := range keys {
+		if key.GetApiKey() != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// 没有配置的字段使用默认值
+
+func retryPolicy(retry *conf.Retry) chat.RetryPolicy {
+	policy := chat.DefaultRetryPolicy
+	if retry.GetMaxAttempts() > 0 {
+		policy.MaxAttempts = int(retry.GetMaxAttempts())
+	}
+	if retry.GetBaseDelay() > 0 {
+		policy.BaseDelay = time.Duration(retry.GetBaseDelay()) * time.Millisecond
+	}
+	if retry.GetMaxDelay() > 0 {
+		policy.MaxDelay = time.Duration(retry.GetMaxDelay()) * time.Millisecond
+	}
+	return policy
+}
+
+// 配置了代理时使用单独的 http.Client，没有配置时返回 nil，各厂商使用默认的 http.Client (支持 HTTPS_PROXY 等环境变量)
+// 代理地址错误时无法启动
+
+func newHttpClient(proxy string) *http.Client {
+	if proxy == "" {
+		return nil
+	}
+	
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil || proxyUrl.Host == "" {
+		panic(fmt.Sprintf("代理地址配置错误: %s", proxy))
+	}
+	
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyUrl)
+	return &http.Client{Transport: transport}
+}
+
+// 只有一个 apikey 时不需要组合
+// 重试在 apikey 池外层，换 apikey 重试不计入重试次数
+
+func newPool(strategy string, retry *conf.Retry, keys []*conf.Credential, newProvider func(c *conf.Credential) chat.Provider) chat.Provider {
+	if len(keys) == 1 {
+		return chat.NewRetryProvider(newProvider(keys[0]), retryPolicy(retry))
+	}
+	
+	var members []chat.PoolMember
+	for _, key := range keys {
+		members = append(members, chat.PoolMember{
+			Provider: newProvider(key),
+			Weight:   int(key.GetWeight()),
+		})
+	}
+	return chat.NewRetryProvider(chat.NewPool(strategy, members...), retryPolicy(retry))
+}
+
+// 多模态、文生图等非对话接口使用的 apikey 池，与对话使用相同的 keys 配置和策略
+
+func newKeyPool[T any](strategy string, keys []*conf.Credential, newKey func(c *conf.Credential) T) *chat.KeyPool[T] {
+	var members []chat.KeyPoolMember[T]
+	for _, key := range keys {
+		members = append(members, chat.KeyPoolMember[T]{
+			Key:    newKey(key),
+			Weight: int(key.GetWeight()),
+		})
+	}
+	return chat.NewKeyPool(strategy, members...)
+}
+
+// 百度千帆 AccessToken, 优先从 LocalCache 中获取
+
+type qianFanAccessToken struct {
+	client         *baiduCloud.Client
+	localCacheRepo LocalCacheRepo
+	apiKey         string
+	secretKey      string
+	logger         *zap.Logger
+}
+
+// 多个 apikey 时每个 apikey 的 AccessToken 分开缓存
+
+func (qianFanAccessToken *qianFanAccessToken) cacheKey() string {
+	return fmt.Sprintf("%s:%s", accessTokenKey, qianFanAccessToken.apiKey)
+}
+
+func (qianFanAccessToken *qianFanAccessToken) Get(ctx context.Context) (string, error) {
+	accessTokenByte, err := qianFanAccessToken.localCacheRepo.GetLocalCache(qianFanAccessToken.cacheKey())
+	
+	if string(accessTokenByte) != "" && err == nil {
+		return string(accessTokenByte), nil
+	}
+	
+	// 获取失败，则通过 API 重新获取Token
+	if err != nil {
+		qianFanAccessToken.logger.Error("从LocalCache中获取AccessToken失败", zap.Error(err))
+	}
+	
+	// 通过 API 获取Token
+	accessToken, err := qianFanAccessToken.client.GetQianFanAccessToken(ctx, qianFanAccessToken.apiKey, qianFanAccessToken.secretKey)
+	if err != nil {
+		qianFanAccessToken.logger.Error("调用百度千帆API获取AccessToken失败", zap.Error(err))
+		return "", err
+	}
+	
+	err = qianFanAccessToken.localCacheRepo.SetLocalCache(qianFanAccessToken.cacheKey(), []byte(accessToken.AccessToken))
+	if err != nil {
+		qianFanAccessToken.logger.Error("设置LocalCache的AccessToken失败", zap.Error(err))
+	}
+	
+	return accessToken.AccessToken, nil
+}
+
+// AccessToken 无效或过期时删除缓存，下次重新获取
+
+func (qianFanAccessToken *qianFanAccessToken) Invalidate() {
+	err := qianFanAccessToken.localCacheRepo.DeleteLocalCache(qianFanAccessToken.cacheKey())
+	if err != nil {
+		qianFanAccessToken.logger.Error("删除LocalCache的AccessToken失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,156 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"github.com/qx66/pedant/pkg/chat"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// 上下文窗口: 从最新的一轮开始向前选取历史上下文，直到超出 token 预算
+// 超出窗口的历史可以生成滚动摘要，保存在 session 表中，作为系统指令的一部分发送给大模型
+
+const (
+	defaultContextMaxTokens = 4096
+	defaultContextMaxTurns  = 50
+	contextRetryTimes       = 2 // 上下文超长时缩小窗口重试的次数
+	summaryPrompt           = "你是一个对话摘要助手。请将已有摘要和新的对话合并为一份简洁的摘要，保留关键事实、用户偏好和未完成的问题，不超过300字，只输出摘要内容。"
+)
+
+func (sessionUseCase *SessionUseCase) contextMaxTokens() int {
+	contextWindow := sessionUseCase.pedant.GetContextWindow()
+	if contextWindow.GetMaxTokens() > 0 {
+		return int(contextWindow.GetMaxTokens())
+	}
+	return defaultContextMaxTokens
+}
+
+func (sessionUseCase *SessionUseCase) contextMaxTurns() int {
+	contextWindow := sessionUseCase.pedant.GetContextWindow()
+	if contextWindow.GetMaxTurns() > 0 {
+		return int(contextWindow.GetMaxTurns())
+	}
+	return defaultContextMaxTurns
+}
+
+// 选取窗口内的历史上下文
+// contexts 按时间倒序 (最新的在前)，返回的 kept 按时间正序，dropped 为超出窗口的历史 (按时间倒序)
+
+func selectContextWindow(contexts []Context, budget int) (kept []Context, dropped []Context) {
+	used := 0
+	for i, c := range contexts {
+		// 缺少提问或回答的轮次不会发送给大模型，不占用预算
+		if c.UserContent == "" || c.AssistantContent == "" {
+			continue
+		}
+		
+		cost := chat.EstimateTokens(c.UserContent) + chat.EstimateTokens(c.AssistantContent) + 8
+		if used+cost > budget {
+			dropped = contexts[i:]
+			break
+		}
+		
+		used += cost
+		kept = append(kept, c)
+	}
+	
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	
+	return kept, dropped
+}
+
+// 在上下文窗口内请求大模型，chatReq.Messages 只包含本轮的提问，历史上下文在窗口内选取后插入到前面
+// 上下文超长时缩小窗口重试，已经输出了内容时不能重试
+
+func (sessionUseCase *SessionUseCase) chatWithinWindow(ctx context.Context, provider chat.Provider, chatReq chat.Request, contexts []Context, budget int, onDelta func(delta string) error) (chat.Response, []Context, error) {
+	messages := chatReq.Messages
+	
+	for i := 0; ; i++ {
+		kept, dropped := selectContextWindow(contexts, budget)
+		chatReq.Messages = append(generateChatContext(kept), messages...)
+		
+		var resp chat.Response
+		var err error
+		if onDelta == nil {
+			resp, err = provider.Chat(ctx, chatReq)
+		} else {
+			resp, err = provider.ChatStream(ctx, chatReq, onDelta)
+		}
+		
+		if err != nil && resp.Content == "" && len(kept) > 0 && i < contextRetryTimes && chat.IsContextLengthError(err) {
+			sessionUseCase.logger.Warn("上下文超长，缩小窗口重试", zap.Int("turns", len(kept)), zap.Error(err))
+			budget = budget / 2
+			continue
+		}
+		
+		return resp, dropped, err
+	}
+}
+
+// 系统指令附带历史摘要
+
+func generateSystemPrompt(system, summary string) string {
+	if summary == "" {
+		return system
+	}
+	return fmt.Sprintf("%s\n\n以下是之前对话的摘要:\n%s", system, summary)
+}
+
+// 对话是否已经包含在摘要中，与上下文的顺序一致按 (create_time, uuid) 比较
+// 没有记录 uuid 的摘要 (添加 summary_uuid 列之前生成的) 包含同一秒内的所有对话
+
+func summarized(session Session, c Context) bool {
+	if c.CreateTime != session.SummaryTime {
+		return c.CreateTime < session.SummaryTime
+	}
+	return session.SummaryUuid == "" || c.Uuid <= session.SummaryUuid
+}
+
+// 将超出窗口且未包含在摘要中的历史合并到会话摘要中
+
+func (sessionUseCase *SessionUseCase) updateSummary(ctx context.Context, provider chat.Provider, model string, session Session, dropped []Context) {
+	var pending []Context
+	for _, c := range dropped {
+		if !summarized(session, c) && c.UserContent != "" && c.AssistantContent != "" {
+			pending = append(pending, c)
+		}
+	}
+	
+	if len(pending) == 0 {
+		return
+	}
+	
+	var content strings.Builder
+	if session.Summary != "" {
+		content.WriteString("已有摘要:\n")
+		content.WriteString(session.Summary)
+		content.WriteString("\n\n")
+	}
+	
+	content.WriteString("新的对话:\n")
+	for i := len(pending) - 1; i >= 0; i-- {
+		content.WriteString("用户: ")
+		content.WriteString(pending[i].UserContent)
+		content.WriteString("\n助理: ")
+		content.WriteString(pending[i].AssistantContent)
+		content.WriteString("\n")
+	}
+	
+	resp, err := provider.Chat(ctx, chat.Request{
+		Model:    model,
+		System:   summaryPrompt,
+		Messages: []chat.Message{{Role: chat.RoleUser, Content: content.String()}},
+	})
+	if err != nil {
+		sessionUseCase.logger.Error("生成会话摘要失败", zap.String("sessionUuid", session.Uuid), zap.Error(err))
+		return
+	}
+	
+	err = sessionUseCase.sessionRepo.UpdateSessionSummary(ctx, session.Uuid, resp.Content, pending[0].CreateTime, pending[0].Uuid)
+	if err != nil {
+		sessionUseCase.logger.Error("保存会话摘要失败", zap.String("sessionUuid", session.Uuid), zap.Error(err))
+	}
+}
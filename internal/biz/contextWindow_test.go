@@ -0,0 +1,152 @@
+package biz
+
+import (
+	"context"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+	"testing"
+)
+
+func TestSelectContextWindow(t *testing.T) {
+	// 每轮 "aaaa" + "bbbb" 占用 1 + 1 + 8 = 10 个 token
+	turn := func(uuid string) Context {
+		return Context{Uuid: uuid, UserContent: "aaaa", AssistantContent: "bbbb"}
+	}
+	
+	tests := []struct {
+		name        string
+		contexts    []Context // 按时间倒序
+		budget      int
+		wantKept    string // 按时间正序
+		wantDropped string // 按时间倒序
+	}{
+		{
+			name:     "没有历史",
+			budget:   100,
+			wantKept: "",
+		},
+		{
+			name:     "全部在窗口内",
+			contexts: []Context{turn("3"), turn("2"), turn("1")},
+			budget:   30,
+			wantKept: "123",
+		},
+		{
+			name:        "超出预算时丢弃更早的历史",
+			contexts:    []Context{turn("3"), turn("2"), turn("1")},
+			budget:      29,
+			wantKept:    "23",
+			wantDropped: "1",
+		},
+		{
+			name:        "最新的一轮超出预算",
+			contexts:    []Context{turn("3"), turn("2"), turn("1")},
+			budget:      9,
+			wantDropped: "321",
+		},
+		{
+			name:     "缺少回答的轮次不占用预算",
+			contexts: []Context{turn("3"), {Uuid: "2", UserContent: "aaaa"}, turn("1")},
+			budget:   20,
+			wantKept: "13",
+		},
+		{
+			name:        "超出预算后不再选取更早的轮次",
+			contexts:    []Context{turn("3"), {Uuid: "2", UserContent: "这是一个很长的问题", AssistantContent: "这是一个很长的回答"}, turn("1")},
+			budget:      20,
+			wantKept:    "3",
+			wantDropped: "21",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := selectContextWindow(tt.contexts, tt.budget)
+			if got := contextUuids(kept); got != tt.wantKept {
+				t.Errorf("kept = %q, want %q", got, tt.wantKept)
+			}
+			if got := contextUuids(dropped); got != tt.wantDropped {
+				t.Errorf("dropped = %q, want %q", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestSummarized(t *testing.T) {
+	session := Session{SummaryTime: 100, SummaryUuid: "b"}
+	
+	tests := []struct {
+		name    string
+		session Session
+		context Context
+		want    bool
+	}{
+		{name: "更早的对话", session: session, context: Context{Uuid: "z", CreateTime: 99}, want: true},
+		{name: "摘要包含的最后一轮", session: session, context: Context{Uuid: "b", CreateTime: 100}, want: true},
+		{name: "同一秒内排在前面", session: session, context: Context{Uuid: "a", CreateTime: 100}, want: true},
+		{name: "同一秒内排在后面", session: session, context: Context{Uuid: "c", CreateTime: 100}, want: false},
+		{name: "更晚的对话", session: session, context: Context{Uuid: "a", CreateTime: 101}, want: false},
+		{name: "没有记录 uuid 的摘要包含同一秒内的所有对话", session: Session{SummaryTime: 100}, context: Context{Uuid: "c", CreateTime: 100}, want: true},
+		{name: "没有摘要", context: Context{Uuid: "a", CreateTime: 1}, want: false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarized(tt.session, tt.context); got != tt.want {
+				t.Errorf("summarized() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSummary(t *testing.T) {
+	repo := &fakeSessionRepo{}
+	provider := &fakeChatProvider{resp: chat.Response{Content: "新的摘要"}}
+	sessionUseCase := newTestSessionUseCase(repo, nil, &conf.Pedant{Llm: OpenAILLM}, map[string]chat.Provider{OpenAILLM: provider})
+	
+	// 摘要包含到 (100, b)，同一秒内的 c 还没有合并到摘要中
+	session := Session{Uuid: "s", Summary: "旧的摘要", SummaryTime: 100, SummaryUuid: "b"}
+	dropped := []Context{
+		{Uuid: "c", UserContent: "问题c", AssistantContent: "回答c", CreateTime: 100},
+		{Uuid: "b", UserContent: "问题b", AssistantContent: "回答b", CreateTime: 100},
+		{Uuid: "a", UserContent: "问题a", AssistantContent: "回答a", CreateTime: 99},
+	}
+	sessionUseCase.updateSummary(context.Background(), provider, "", session, dropped)
+	
+	content := provider.requests[0].Messages[0].Content
+	if !strings.Contains(content, "旧的摘要") || !strings.Contains(content, "问题c") || strings.Contains(content, "问题b") || strings.Contains(content, "问题a") {
+		t.Errorf("summary request = %q", content)
+	}
+	
+	want := Session{Uuid: "s", Summary: "新的摘要", SummaryTime: 100, SummaryUuid: "c"}
+	if repo.summary != want {
+		t.Errorf("saved summary = %+v, want %+v", repo.summary, want)
+	}
+	
+	// 没有新的对话时不请求大模型
+	session = repo.summary
+	sessionUseCase.updateSummary(context.Background(), provider, "", session, dropped)
+	if len(provider.requests) != 1 {
+		t.Errorf("requests = %d, want 1", len(provider.requests))
+	}
+}
+
+func TestGenerateSystemPrompt(t *testing.T) {
+	if got := generateSystemPrompt("你是一个助手", ""); got != "你是一个助手" {
+		t.Errorf("generateSystemPrompt() without summary = %q", got)
+	}
+	
+	want := "你是一个助手\n\n以下是之前对话的摘要:\n用户喜欢 Go"
+	if got := generateSystemPrompt("你是一个助手", "用户喜欢 Go"); got != want {
+		t.Errorf("generateSystemPrompt() = %q, want %q", got, want)
+	}
+}
+
+func contextUuids(contexts []Context) string {
+	var uuids string
+	for _, c := range contexts {
+		uuids += c.Uuid
+	}
+	return uuids
+}
@@ -0,0 +1,27 @@
+package biz
+
+// 失败时依次尝试的大模型: 当前大模型 + pedant.fallback，跳过重复和未配置 apikey 的大模型
+
+func (sessionUseCase *SessionUseCase) fallbackChain(llm string) []string {
+	chain := []string{llm}
+	for _, name := range sessionUseCase.pedant.GetFallback() {
+		if containsString(chain, name) {
+			continue
+		}
+		
+		if _, ok := sessionUseCase.chatRegistry.Get(name); !ok {
+			continue
+		}
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
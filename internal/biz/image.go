@@ -6,6 +6,7 @@ import (
 	"github.com/qx66/pedant/internal/biz/common"
 	"github.com/qx66/pedant/internal/conf"
 	"github.com/qx66/pedant/pkg/baiduCloud"
+	"github.com/qx66/pedant/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/startopsz/rule/pkg/response/errCode"
@@ -30,46 +31,70 @@ func (image Image) TableName() string {
 
 type ImageRepo interface {
 	CreateImage(ctx context.Context, image Image) error
-	ListImage(ctx context.Context, userUuid string) ([]Image, error)
+	ListImage(ctx context.Context, userUuid string, page Page) ([]Image, error)
 }
 
 type ImageUseCase struct {
 	imageRepo      ImageRepo
 	localCacheRepo LocalCacheRepo
+	quotaUseCase   *QuotaUseCase
 	pedant         *conf.Pedant
 	llm            *conf.Llm
+	qianfanClient  *baiduCloud.Client
+	qianfanKeys    *chat.KeyPool[*qianFanAccessToken]
 	logger         *zap.Logger
 }
 
-func NewImageUseCase(imageRepo ImageRepo, localCacheRepo LocalCacheRepo, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *ImageUseCase {
+func NewImageUseCase(imageRepo ImageRepo, localCacheRepo LocalCacheRepo, quotaUseCase *QuotaUseCase, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *ImageUseCase {
+	qianfanKeys := credentials(llm.GetQianfan().GetApiKey(), llm.GetQianfan().GetSecretKey(), llm.GetQianfan().GetKeys())
+	
+	// apikey 可以通过 apiKey 或 keys 配置
 	switch pedant.ImageLlm {
 	case OpenAILLM:
-		if llm.Openai.ApiKey == "" {
+		if len(credentials(llm.GetOpenai().GetApiKey(), "", llm.GetOpenai().GetKeys())) == 0 {
 			panic("配置使用openai大模型语言，但未配置apikey")
 		}
 	case GoogleLLM:
-		if llm.Gemini.ApiKey == "" {
+		if len(credentials(llm.GetGemini().GetApiKey(), "", llm.GetGemini().GetKeys())) == 0 {
 			panic("配置使用google大模型语言，但未配置apikey")
 		}
 	case BaiduCloudLLM:
-		if llm.Qianfan.ApiKey == "" || llm.Qianfan.SecretKey == "" {
+		if len(qianfanKeys) == 0 {
 			panic("配置使用百度云大模型语言，但未配置apikey/secretKey")
 		}
+		for _, key := range qianfanKeys {
+			if key.GetSecretKey() == "" {
+				panic("配置使用百度云大模型语言，但未配置apikey/secretKey")
+			}
+		}
 	default:
 		panic("配置使用未知的大模型语言")
 	}
 	
+	client := baiduCloud.NewClient(llm.GetQianfan().GetBaseUrl(), newHttpClient(llm.GetQianfan().GetProxy()))
 	return &ImageUseCase{
 		imageRepo:      imageRepo,
 		localCacheRepo: localCacheRepo,
+		quotaUseCase:   quotaUseCase,
 		pedant:         pedant,
 		llm:            llm,
-		logger:         logger,
+		qianfanClient:  client,
+		qianfanKeys: newKeyPool(llm.GetQianfan().GetStrategy(), qianfanKeys, func(c *conf.Credential) *qianFanAccessToken {
+			return &qianFanAccessToken{
+				client:         client,
+				localCacheRepo: localCacheRepo,
+				apiKey:         c.ApiKey,
+				secretKey:      c.SecretKey,
+				logger:         logger,
+			}
+		}),
+		logger: logger,
 	}
 }
 
 type GetImageReq struct {
-	UserUuid string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	PageReq
 }
 
 func (imageUseCase *ImageUseCase) Get(c *gin.Context) {
@@ -79,18 +104,30 @@ func (imageUseCase *ImageUseCase) Get(c *gin.Context) {
 		return
 	}
 	
-	images, err := imageUseCase.imageRepo.ListImage(c.Request.Context(), req.UserUuid)
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	page, err := req.page(OrderDesc)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
+	images, err := imageUseCase.imageRepo.ListImage(c.Request.Context(), req.UserUuid, page)
 	if err != nil {
 		imageUseCase.logger.Error("查询数据库失败", zap.Error(err))
 		c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "images": images})
+	images, nextPageToken := nextPage(images, page, func(image Image) (int64, string) { return image.CreateTime, image.Uuid })
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "images": images, "nextPageToken": nextPageToken})
 }
 
 type GenerateImageReq struct {
-	UserUuid       string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid       string `json:"userUuid,omitempty" form:"userUuid"`
 	Prompt         string `json:"prompt,omitempty" form:"prompt" validate:"required"`
 	NegativePrompt string `json:"negativePrompt" form:"negativePrompt"`
 	Count          int    `json:"count,omitempty" form:"count"`
@@ -103,6 +140,11 @@ func (imageUseCase *ImageUseCase) Create(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
 	//
 	switch imageUseCase.pedant.ImageLlm {
 	case OpenAILLM:
@@ -114,10 +156,7 @@ func (imageUseCase *ImageUseCase) Create(c *gin.Context) {
 		return
 	
 	case BaiduCloudLLM:
-		//sessionUseCase.llm.Qianfan
-		token, err := imageUseCase.GetAccessToken(c.Request.Context())
-		if err != nil {
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		if !imageUseCase.quotaUseCase.allow(c, req.UserUuid, BaiduCloudLLM) {
 			return
 		}
 		
@@ -128,7 +167,15 @@ func (imageUseCase *ImageUseCase) Create(c *gin.Context) {
 			N:              req.Count,
 			SamplerIndex:   baiduCloud.StableDiffusionSamplerIndexEuler,
 		}
-		resp, err := baiduCloud.GenerateStableDiffusionXLImage(token, generateImageReq)
+		var resp baiduCloud.StableDiffusionXLResponse
+		err = retryPolicy(imageUseCase.llm.Qianfan.GetRetry()).Do(c.Request.Context(), func() error {
+			return imageUseCase.qianfanKeys.Do(c.Request.Context(), func(accessToken *qianFanAccessToken) error {
+				return baiduCloud.WithAccessToken(c.Request.Context(), accessToken, func(token string) error {
+					resp, err = imageUseCase.qianfanClient.GenerateStableDiffusionXLImage(c.Request.Context(), token, generateImageReq)
+					return err
+				})
+			})
+		})
 		
 		if err != nil {
 			imageUseCase.logger.Error("请求百度云API失败", zap.Error(err))
@@ -158,6 +205,7 @@ func (imageUseCase *ImageUseCase) Create(c *gin.Context) {
 			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 			return
 		}
+		imageUseCase.quotaUseCase.Record(c.Request.Context(), req.UserUuid, BaiduCloudLLM, resp.Usage.TotalTokens)
 		
 		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": resp})
 		return
@@ -167,34 +215,3 @@ func (imageUseCase *ImageUseCase) Create(c *gin.Context) {
 		return
 	}
 }
-
-func (imageUseCase *ImageUseCase) GetAccessToken(ctx context.Context) (string, error) {
-	accessTokenByte, err := imageUseCase.localCacheRepo.GetLocalCache(accessTokenKey)
-	
-	if string(accessTokenByte) != "" && err == nil {
-		return string(accessTokenByte), nil
-	}
-	
-	// 获取失败，则通过 API 重新获取Token
-	if err != nil {
-		imageUseCase.logger.Error("从LocalCache中获取AccessToken失败", zap.Error(err))
-	}
-	
-	// 通过 API 获取Token
-	accessToken, err := baiduCloud.GetQianFanAccessToken(imageUseCase.llm.Qianfan.ApiKey, imageUseCase.llm.Qianfan.SecretKey)
-	if err != nil {
-		imageUseCase.logger.Error("调用百度千帆API获取AccessToken失败", zap.Error(err))
-		return "", err
-	}
-	
-	err = imageUseCase.localCacheRepo.SetLocalCache(accessTokenKey, []byte(accessToken.AccessToken))
-	if err != nil {
-		imageUseCase.logger.Error("设置LocalCache的AccessToken失败", zap.Error(err))
-	}
-	
-	return accessToken.AccessToken, nil
-}
-
-func (imageUseCase *ImageUseCase) GetAccessTokenByLocalCache(ctx context.Context) ([]byte, error) {
-	return imageUseCase.localCacheRepo.GetLocalCache(accessTokenKey)
-}
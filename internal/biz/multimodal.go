@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/qx66/pedant/internal/biz/common"
 	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/chat"
 	"github.com/qx66/pedant/pkg/gemini"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,6 +21,11 @@ type MultiModal struct {
 	Images           []byte `json:"images,omitempty"`
 	AssistantContent string `json:"assistantContent,omitempty"`
 	Llm              string `json:"llm,omitempty"`
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+	TotalTokens      int    `json:"totalTokens,omitempty"`
+	FinishReason     string `json:"finishReason,omitempty"`
+	BlockReason      string `json:"blockReason,omitempty"` // 被安全策略拦截的原因，被拦截时没有返回内容
 	CreateTime       int64  `json:"createTime,omitempty"`
 }
 
@@ -29,29 +35,43 @@ func (multiModal MultiModal) TableName() string {
 
 type MultiModalRepo interface {
 	CreateMultiModal(ctx context.Context, multiModal MultiModal) error
-	ListMultiModal(ctx context.Context, userUuid string) ([]MultiModal, error)
+	ListMultiModal(ctx context.Context, userUuid string, page Page) ([]MultiModal, error)
 }
 
 type MultiModalUseCase struct {
 	multiModalRepo MultiModalRepo
 	localCacheRepo LocalCacheRepo
+	quotaUseCase   *QuotaUseCase
 	pedant         *conf.Pedant
 	llm            *conf.Llm
+	geminiKeys     *chat.KeyPool[*gemini.RestClient] // 没有配置 apikey 时为 nil
 	logger         *zap.Logger
 }
 
-func NewMultiModalUseCase(multiModalRepo MultiModalRepo, localCacheRepo LocalCacheRepo, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *MultiModalUseCase {
-	return &MultiModalUseCase{
+func NewMultiModalUseCase(multiModalRepo MultiModalRepo, localCacheRepo LocalCacheRepo, quotaUseCase *QuotaUseCase, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *MultiModalUseCase {
+	multiModalUseCase := &MultiModalUseCase{
 		multiModalRepo: multiModalRepo,
 		localCacheRepo: localCacheRepo,
+		quotaUseCase:   quotaUseCase,
 		pedant:         pedant,
 		llm:            llm,
 		logger:         logger,
 	}
+	
+	geminiKeys := credentials(llm.GetGemini().GetApiKey(), "", llm.GetGemini().GetKeys())
+	if len(geminiKeys) > 0 {
+		cli := newHttpClient(llm.GetGemini().GetProxy())
+		multiModalUseCase.geminiKeys = newKeyPool(llm.GetGemini().GetStrategy(), geminiKeys, func(c *conf.Credential) *gemini.RestClient {
+			return gemini.NewRestClient(c.ApiKey, llm.GetGemini().GetBaseUrl(), cli)
+		})
+	}
+	
+	return multiModalUseCase
 }
 
 type GetMultiModalReq struct {
-	UserUuid string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	PageReq
 }
 
 func (multiModalUseCase *MultiModalUseCase) Get(c *gin.Context) {
@@ -61,18 +81,30 @@ func (multiModalUseCase *MultiModalUseCase) Get(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	page, err := req.page(OrderDesc)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
 	//
-	contents, err := multiModalUseCase.multiModalRepo.ListMultiModal(c.Request.Context(), req.UserUuid)
+	contents, err := multiModalUseCase.multiModalRepo.ListMultiModal(c.Request.Context(), req.UserUuid, page)
 	if err != nil {
 		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": contents})
+	contents, nextPageToken := nextPage(contents, page, func(content MultiModal) (int64, string) { return content.CreateTime, content.Uuid })
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": contents, "nextPageToken": nextPageToken})
 }
 
 type CreateMultiModalReq struct {
-	UserUuid string   `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid string   `json:"userUuid,omitempty" form:"userUuid"`
 	Content  string   `json:"content,omitempty" form:"content" validate:"required"`
 	Images   []string `json:"images,omitempty" form:"images" validate:"required"`
 }
@@ -84,6 +116,11 @@ func (multiModalUseCase *MultiModalUseCase) Create(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
 	//
 	imageByte, err := json.Marshal(req.Images)
 	if err != nil {
@@ -105,33 +142,63 @@ func (multiModalUseCase *MultiModalUseCase) Create(c *gin.Context) {
 		return
 	
 	case GoogleLLM:
-		apiKey := multiModalUseCase.llm.Gemini.ApiKey
-		k := gemini.ApiKey(apiKey)
+		if multiModalUseCase.geminiKeys == nil {
+			c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": "UnSupport LLM"})
+			return
+		}
+		
+		if !multiModalUseCase.quotaUseCase.allow(c, req.UserUuid, GoogleLLM) {
+			return
+		}
 		
-		resp, err := k.MultiModal(req.Content, req.Images...)
+		// 重试在 apikey 池外层，与对话一致
+		var resp gemini.Response
+		err = retryPolicy(multiModalUseCase.llm.Gemini.GetRetry()).Do(c.Request.Context(), func() error {
+			return multiModalUseCase.geminiKeys.Do(c.Request.Context(), func(k *gemini.RestClient) error {
+				resp, err = k.MultiModal(c.Request.Context(), req.Content, req.Images...)
+				return err
+			})
+		})
 		if err != nil {
 			multiModalUseCase.logger.Error("请求Google Gemini Api失败", zap.Error(err))
 			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 			return
 		}
 		
-		err = multiModalUseCase.multiModalRepo.CreateMultiModal(c.Request.Context(), MultiModal{
+		usage := resp.Usage()
+		multiModal := MultiModal{
 			Uuid:             uuid.NewString(),
 			UserUuid:         req.UserUuid,
 			UserContent:      req.Content,
 			Images:           imageByte,
-			AssistantContent: resp.Candidates[0].Content.Parts[0].Text,
-			Llm:              multiModalUseCase.pedant.Llm,
+			AssistantContent: resp.Text(),
+			Llm:              GoogleLLM,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+			FinishReason:     resp.FinishReason(),
+			BlockReason:      resp.BlockReason(),
 			CreateTime:       time.Now().Unix(),
-		})
+		}
+		
+		// 被拦截的请求也保存，便于统计用量和排查
+		err = multiModalUseCase.multiModalRepo.CreateMultiModal(c.Request.Context(), multiModal)
 		if err != nil {
 			multiModalUseCase.logger.Error("插入数据库失败", zap.Error(err))
 			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 			return
 		}
+		multiModalUseCase.quotaUseCase.Record(c.Request.Context(), req.UserUuid, GoogleLLM, usage.TotalTokens)
+		
+		if len(resp.Candidates) == 0 {
+			multiModalUseCase.logger.Info("Google Gemini 拦截了请求", zap.String("blockReason", multiModal.BlockReason))
+			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": "content blocked", "uuid": multiModal.Uuid, "blockReason": multiModal.BlockReason})
+			return
+		}
 		
 		//c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "resp": resp})
-		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": resp.Candidates[0].Content.Parts[0].Text})
+		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": multiModal.AssistantContent,
+			"uuid": multiModal.Uuid, "finishReason": multiModal.FinishReason, "blockReason": multiModal.BlockReason, "usage": usage})
 		return
 	
 	case BaiduCloudLLM:
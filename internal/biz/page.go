@@ -0,0 +1,96 @@
+package biz
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// 列表接口的游标分页，按 (create_time, uuid) 排序，翻页时数据有增删也不会重复或遗漏
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+var errPageToken = errors.New("invalid pageToken")
+
+// PageReq 嵌入到列表接口的请求参数中
+
+type PageReq struct {
+	PageToken string `json:"pageToken,omitempty" form:"pageToken"` // 上一页返回的 nextPageToken
+	PageSize  int    `json:"pageSize,omitempty" form:"pageSize" validate:"omitempty,min=1,max=100"`
+	StartTime int64  `json:"startTime,omitempty" form:"startTime"` // create_time >= startTime (unix 秒)
+	EndTime   int64  `json:"endTime,omitempty" form:"endTime"`     // create_time < endTime (unix 秒)
+	Order     string `json:"order,omitempty" form:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// Page 传递给 data 层的分页条件，data 层多查询一条用于判断是否还有下一页
+
+type Page struct {
+	Size      int
+	StartTime int64
+	EndTime   int64
+	Desc      bool
+	After     *Cursor // 为空时从第一条开始
+}
+
+type Cursor struct {
+	CreateTime int64  `json:"t"`
+	Uuid       string `json:"u"`
+	Desc       bool   `json:"d,omitempty"`
+}
+
+// 解析分页参数，defaultOrder 为各接口原来的排序
+// 翻页时排序必须与 pageToken 一致
+
+func (req PageReq) page(defaultOrder string) (Page, error) {
+	page := Page{
+		Size:      req.PageSize,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Desc:      req.Order == OrderDesc || (req.Order == "" && defaultOrder == OrderDesc),
+	}
+	
+	if page.Size <= 0 {
+		page.Size = defaultPageSize
+	}
+	
+	if page.Size > maxPageSize {
+		page.Size = maxPageSize
+	}
+	
+	if req.PageToken == "" {
+		return page, nil
+	}
+	
+	b, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+	if err != nil {
+		return page, errPageToken
+	}
+	
+	var cursor Cursor
+	err = json.Unmarshal(b, &cursor)
+	if err != nil || cursor.Desc != page.Desc {
+		return page, errPageToken
+	}
+	
+	page.After = &cursor
+	return page, nil
+}
+
+// 截取当前页，还有下一页时返回 nextPageToken
+
+func nextPage[T any](items []T, page Page, key func(item T) (int64, string)) ([]T, string) {
+	if len(items) <= page.Size {
+		return items, ""
+	}
+	
+	items = items[:page.Size]
+	createTime, uuid := key(items[len(items)-1])
+	b, _ := json.Marshal(Cursor{CreateTime: createTime, Uuid: uuid, Desc: page.Desc})
+	return items, base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package biz
+
+import (
+	"testing"
+)
+
+func TestPageReq(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          PageReq
+		defaultOrder string
+		wantSize     int
+		wantDesc     bool
+		wantErr      bool
+	}{
+		{name: "默认值", req: PageReq{}, defaultOrder: OrderAsc, wantSize: defaultPageSize},
+		{name: "默认倒序", req: PageReq{}, defaultOrder: OrderDesc, wantSize: defaultPageSize, wantDesc: true},
+		{name: "指定顺序", req: PageReq{Order: OrderAsc}, defaultOrder: OrderDesc, wantSize: defaultPageSize},
+		{name: "最大条数", req: PageReq{PageSize: 1000}, defaultOrder: OrderAsc, wantSize: maxPageSize},
+		{name: "非法 pageToken", req: PageReq{PageToken: "不是 base64"}, defaultOrder: OrderAsc, wantErr: true},
+		{name: "非法 json", req: PageReq{PageToken: "bm90IGpzb24"}, defaultOrder: OrderAsc, wantErr: true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tt.req.page(tt.defaultOrder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("page() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if page.Size != tt.wantSize || page.Desc != tt.wantDesc || page.After != nil {
+				t.Errorf("page() = %+v, want size %d, desc %v", page, tt.wantSize, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	items := []Session{
+		{Uuid: "a", CreateTime: 1},
+		{Uuid: "b", CreateTime: 2},
+		{Uuid: "c", CreateTime: 2},
+	}
+	key := func(session Session) (int64, string) {
+		return session.CreateTime, session.Uuid
+	}
+	
+	// data 层多查询一条，不超过 Size 时没有下一页
+	got, token := nextPage(items, Page{Size: 3}, key)
+	if len(got) != 3 || token != "" {
+		t.Fatalf("nextPage() = %d items, token %q, want 3 items, no token", len(got), token)
+	}
+	
+	got, token = nextPage(items, Page{Size: 2, Desc: true}, key)
+	if len(got) != 2 || token == "" {
+		t.Fatalf("nextPage() = %d items, token %q, want 2 items and token", len(got), token)
+	}
+	
+	// 下一页从当前页的最后一条之后开始
+	page, err := PageReq{PageToken: token, PageSize: 2}.page(OrderDesc)
+	if err != nil {
+		t.Fatalf("page() error = %v", err)
+	}
+	if page.After == nil || *page.After != (Cursor{CreateTime: 2, Uuid: "b", Desc: true}) {
+		t.Errorf("page.After = %+v, want {2 b true}", page.After)
+	}
+	
+	// 翻页时不能改变排序
+	_, err = PageReq{PageToken: token, Order: OrderAsc}.page(OrderDesc)
+	if err != errPageToken {
+		t.Errorf("page() with changed order error = %v, want %v", err, errPageToken)
+	}
+}
@@ -0,0 +1,186 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Persona 保存的系统指令，会话可以通过 personaUuid 引用
+
+type Persona struct {
+	Uuid         string `json:"uuid,omitempty"`
+	UserUuid     string `json:"userUuid,omitempty"`
+	Name         string `json:"name,omitempty"`
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	CreateTime   int64  `json:"createTime,omitempty"`
+	UpdateTime   int64  `json:"updateTime,omitempty"`
+}
+
+func (persona Persona) TableName() string {
+	return "persona"
+}
+
+type PersonaRepo interface {
+	CreatePersona(ctx context.Context, persona Persona) error
+	ListPersona(ctx context.Context, userUuid string) ([]Persona, error)
+	GetPersona(ctx context.Context, uuid, userUuid string) (Persona, error)
+	UpdatePersona(ctx context.Context, persona Persona) error
+	DeletePersona(ctx context.Context, uuid, userUuid string) error
+}
+
+type PersonaUseCase struct {
+	personaRepo PersonaRepo
+	logger      *zap.Logger
+}
+
+func NewPersonaUseCase(personaRepo PersonaRepo, logger *zap.Logger) *PersonaUseCase {
+	return &PersonaUseCase{
+		personaRepo: personaRepo,
+		logger:      logger,
+	}
+}
+
+type ListPersonaReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+}
+
+func (personaUseCase *PersonaUseCase) ListPersona(c *gin.Context) {
+	req := ListPersonaReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	personas, err := personaUseCase.personaRepo.ListPersona(c.Request.Context(), req.UserUuid)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "personas": personas})
+}
+
+type CreatePersonaReq struct {
+	UserUuid     string `json:"userUuid,omitempty"`
+	Name         string `json:"name,omitempty"  validate:"required"`
+	SystemPrompt string `json:"systemPrompt,omitempty"  validate:"required"`
+}
+
+func (personaUseCase *PersonaUseCase) CreatePersona(c *gin.Context) {
+	req := CreatePersonaReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	now := time.Now().Unix()
+	persona := Persona{
+		Uuid:         uuid.NewString(),
+		UserUuid:     req.UserUuid,
+		Name:         req.Name,
+		SystemPrompt: req.SystemPrompt,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+	
+	err = personaUseCase.personaRepo.CreatePersona(c.Request.Context(), persona)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "uuid": persona.Uuid})
+}
+
+// 字段为空表示不修改
+
+type UpdatePersonaReq struct {
+	UserUuid     string `json:"userUuid,omitempty"`
+	Uuid         string `json:"uuid,omitempty"  validate:"required"`
+	Name         string `json:"name,omitempty"`
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+}
+
+func (personaUseCase *PersonaUseCase) UpdatePersona(c *gin.Context) {
+	req := UpdatePersonaReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	persona, err := personaUseCase.personaRepo.GetPersona(c.Request.Context(), req.Uuid, req.UserUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+			return
+		}
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	if req.Name != "" {
+		persona.Name = req.Name
+	}
+	
+	if req.SystemPrompt != "" {
+		persona.SystemPrompt = req.SystemPrompt
+	}
+	persona.UpdateTime = time.Now().Unix()
+	
+	err = personaUseCase.personaRepo.UpdatePersona(c.Request.Context(), persona)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "persona": persona})
+}
+
+type DelPersonaReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Uuid     string `json:"uuid" form:"uuid"  validate:"required"`
+}
+
+// 删除后引用该 persona 的会话使用默认的系统指令
+
+func (personaUseCase *PersonaUseCase) DelPersona(c *gin.Context) {
+	req := DelPersonaReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	err = personaUseCase.personaRepo.DeletePersona(c.Request.Context(), req.Uuid, req.UserUuid)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg})
+}
@@ -0,0 +1,285 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+// 限流计数，memory 只适用于单实例，多实例部署时使用 mysql 共享计数
+
+const (
+	QuotaStoreMemory = "memory"
+	QuotaStoreMysql  = "mysql"
+)
+
+type QuotaRepo interface {
+	IncrQuotaCounter(ctx context.Context, key string, delta int64, expireAt time.Time) (int64, error)
+	GetQuotaCounter(ctx context.Context, key string) (int64, error)
+}
+
+var (
+	errRateLimited   = errors.New("too many requests")
+	errQuotaExceeded = errors.New("token quota exceeded")
+)
+
+// QuotaStatus 本次请求后剩余的额度，-1 表示没有限制
+
+type QuotaStatus struct {
+	RequestLimit     int64 `json:"requestLimit"`
+	RequestRemaining int64 `json:"requestRemaining"`
+	DailyRemaining   int64 `json:"dailyRemaining"`
+	MonthlyRemaining int64 `json:"monthlyRemaining"`
+	Reset            int64 `json:"reset,omitempty"` // 超出限制时，额度恢复的时间 (unix 秒)
+}
+
+// 限制的对象: 用户或 token
+
+type quotaSubject struct {
+	kind  string
+	id    string
+	limit *conf.Limit
+}
+
+func (subject quotaSubject) key(parts ...string) string {
+	key := "quota:" + subject.kind + ":" + subject.id
+	for _, part := range parts {
+		key += ":" + part
+	}
+	return key
+}
+
+type QuotaUseCase struct {
+	quotaRepo   QuotaRepo
+	user        *conf.Limit
+	token       *conf.Limit
+	tokenLimits map[string]*conf.Limit
+	now         func() time.Time // 当前时间，测试时替换
+	logger      *zap.Logger
+}
+
+func NewQuotaUseCase(quotaRepo QuotaRepo, pedant *conf.Pedant, logger *zap.Logger) *QuotaUseCase {
+	switch pedant.GetQuota().GetStore() {
+	case "", QuotaStoreMemory, QuotaStoreMysql:
+	default:
+		panic(fmt.Sprintf("pedant.quota 配置错误，未知的 store: %s", pedant.GetQuota().GetStore()))
+	}
+	
+	tokenLimits := make(map[string]*conf.Limit)
+	for _, token := range pedant.GetTokens() {
+		if token.GetQuota() != nil {
+			tokenLimits[token.GetName()] = token.GetQuota()
+		}
+	}
+	
+	return &QuotaUseCase{
+		quotaRepo:   quotaRepo,
+		user:        pedant.GetQuota().GetUser(),
+		token:       pedant.GetQuota().GetToken(),
+		tokenLimits: tokenLimits,
+		now:         time.Now,
+		logger:      logger,
+	}
+}
+
+func (quotaUseCase *QuotaUseCase) subjects(ctx context.Context, userUuid string) []quotaSubject {
+	var subjects []quotaSubject
+	if quotaUseCase.user != nil && userUuid != "" {
+		subjects = append(subjects, quotaSubject{kind: "user", id: userUuid, limit: quotaUseCase.user})
+	}
+	
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return subjects
+	}
+	
+	limit, ok := quotaUseCase.tokenLimits[identity.Name]
+	if !ok {
+		limit = quotaUseCase.token
+	}
+	
+	if limit != nil {
+		subjects = append(subjects, quotaSubject{kind: "token", id: identity.Name, limit: limit})
+	}
+	return subjects
+}
+
+// Check 请求大模型前检查 token 预算，并计入每分钟的请求次数
+// 计数失败时只记录日志，不影响请求
+
+func (quotaUseCase *QuotaUseCase) Check(ctx context.Context, userUuid, llm string) (QuotaStatus, error) {
+	subjects := quotaUseCase.subjects(ctx, userUuid)
+	now := quotaUseCase.now()
+	
+	// 先检查预算，超出预算的请求不计入请求次数
+	status, err := quotaUseCase.checkBudget(ctx, subjects, llm, now)
+	if err != nil {
+		return status, err
+	}
+	
+	// 固定窗口计数
+	window := now.Truncate(time.Minute)
+	for _, subject := range subjects {
+		limit := int64(subject.limit.GetRequestsPerMinute())
+		if limit <= 0 {
+			continue
+		}
+		
+		count, e := quotaUseCase.quotaRepo.IncrQuotaCounter(ctx, subject.key("rpm", window.Format("200601021504")), 1, window.Add(2*time.Minute))
+		if e != nil {
+			quotaUseCase.logger.Error("更新限流计数失败", zap.Error(e))
+			continue
+		}
+		
+		// 返回剩余次数最少的限制
+		if status.RequestLimit < 0 || limit-count < status.RequestRemaining {
+			status.RequestLimit = limit
+			status.RequestRemaining = max(limit-count, 0)
+		}
+		
+		if count > limit {
+			err = errRateLimited
+			status.Reset = maxReset(status.Reset, window.Add(time.Minute))
+		}
+	}
+	
+	return status, err
+}
+
+// CheckBudget 只检查 token 预算，用于切换到备用大模型前检查，同一个请求不重复计入请求次数
+
+func (quotaUseCase *QuotaUseCase) CheckBudget(ctx context.Context, userUuid, llm string) error {
+	_, err := quotaUseCase.checkBudget(ctx, quotaUseCase.subjects(ctx, userUuid), llm, quotaUseCase.now())
+	return err
+}
+
+func (quotaUseCase *QuotaUseCase) checkBudget(ctx context.Context, subjects []quotaSubject, llm string, now time.Time) (QuotaStatus, error) {
+	status := QuotaStatus{RequestLimit: -1, RequestRemaining: -1, DailyRemaining: -1, MonthlyRemaining: -1}
+	
+	var err error
+	for _, subject := range subjects {
+		for _, budget := range subject.limit.GetBudgets() {
+			if budget.GetLlm() != "" && budget.GetLlm() != llm {
+				continue
+			}
+			
+			if budget.GetDaily() > 0 {
+				used := quotaUseCase.get(ctx, subject.key(budgetLlm(budget), "day", now.Format("20060102")))
+				status.DailyRemaining = minRemaining(status.DailyRemaining, max(budget.GetDaily()-used, 0))
+				if used >= budget.GetDaily() {
+					err = errQuotaExceeded
+					status.Reset = maxReset(status.Reset, startOfDay(now).AddDate(0, 0, 1))
+				}
+			}
+			
+			if budget.GetMonthly() > 0 {
+				used := quotaUseCase.get(ctx, subject.key(budgetLlm(budget), "month", now.Format("200601")))
+				status.MonthlyRemaining = minRemaining(status.MonthlyRemaining, max(budget.GetMonthly()-used, 0))
+				if used >= budget.GetMonthly() {
+					err = errQuotaExceeded
+					status.Reset = maxReset(status.Reset, startOfDay(now).AddDate(0, 1, 1-now.Day()))
+				}
+			}
+		}
+	}
+	
+	return status, err
+}
+
+// Record 大模型返回后计入 token 用量，llm 为实际使用的大模型 (可能是备用大模型)
+
+func (quotaUseCase *QuotaUseCase) Record(ctx context.Context, userUuid, llm string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	
+	now := quotaUseCase.now()
+	for _, subject := range quotaUseCase.subjects(ctx, userUuid) {
+		for _, budget := range subject.limit.GetBudgets() {
+			if budget.GetLlm() != "" && budget.GetLlm() != llm {
+				continue
+			}
+			
+			if budget.GetDaily() > 0 {
+				quotaUseCase.incr(ctx, subject.key(budgetLlm(budget), "day", now.Format("20060102")), int64(tokens), startOfDay(now).AddDate(0, 0, 2))
+			}
+			
+			if budget.GetMonthly() > 0 {
+				quotaUseCase.incr(ctx, subject.key(budgetLlm(budget), "month", now.Format("200601")), int64(tokens), startOfDay(now).AddDate(0, 2, 1-now.Day()))
+			}
+		}
+	}
+}
+
+func (quotaUseCase *QuotaUseCase) get(ctx context.Context, key string) int64 {
+	value, err := quotaUseCase.quotaRepo.GetQuotaCounter(ctx, key)
+	if err != nil {
+		quotaUseCase.logger.Error("查询限流计数失败", zap.String("key", key), zap.Error(err))
+		return 0
+	}
+	return value
+}
+
+func (quotaUseCase *QuotaUseCase) incr(ctx context.Context, key string, delta int64, expireAt time.Time) {
+	_, err := quotaUseCase.quotaRepo.IncrQuotaCounter(ctx, key, delta, expireAt)
+	if err != nil {
+		quotaUseCase.logger.Error("更新限流计数失败", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// 检查额度并设置响应头，超出限制时直接返回 429
+
+func (quotaUseCase *QuotaUseCase) allow(c *gin.Context, userUuid, llm string) bool {
+	status, err := quotaUseCase.Check(c.Request.Context(), userUuid, llm)
+	
+	if status.RequestLimit >= 0 {
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(status.RequestLimit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(status.RequestRemaining, 10))
+	}
+	
+	if status.DailyRemaining >= 0 {
+		c.Header("X-Quota-Daily-Remaining", strconv.FormatInt(status.DailyRemaining, 10))
+	}
+	
+	if status.MonthlyRemaining >= 0 {
+		c.Header("X-Quota-Monthly-Remaining", strconv.FormatInt(status.MonthlyRemaining, 10))
+	}
+	
+	if err == nil {
+		return true
+	}
+	
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(status.Reset, 10))
+	c.Header("Retry-After", strconv.FormatInt(max(status.Reset-quotaUseCase.now().Unix(), 1), 10))
+	c.JSON(429, gin.H{"errCode": errCode.LimitRequestCode, "errMsg": err.Error(), "quota": status})
+	return false
+}
+
+func budgetLlm(budget *conf.Budget) string {
+	if budget.GetLlm() == "" {
+		return "*"
+	}
+	return budget.GetLlm()
+}
+
+func minRemaining(current, remaining int64) int64 {
+	if current < 0 || remaining < current {
+		return remaining
+	}
+	return current
+}
+
+func maxReset(current int64, reset time.Time) int64 {
+	return max(current, reset.Unix())
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,266 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/chat"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 计数不过期，不同窗口使用不同的 key
+
+type fakeQuotaRepo struct {
+	counters map[string]int64
+}
+
+func (fake *fakeQuotaRepo) IncrQuotaCounter(ctx context.Context, key string, delta int64, expireAt time.Time) (int64, error) {
+	fake.counters[key] += delta
+	return fake.counters[key], nil
+}
+
+func (fake *fakeQuotaRepo) GetQuotaCounter(ctx context.Context, key string) (int64, error) {
+	return fake.counters[key], nil
+}
+
+func newTestQuotaUseCase(pedant *conf.Pedant, now *time.Time) (*QuotaUseCase, *fakeQuotaRepo) {
+	repo := &fakeQuotaRepo{counters: make(map[string]int64)}
+	quotaUseCase := NewQuotaUseCase(repo, pedant, zap.NewNop())
+	quotaUseCase.now = func() time.Time { return *now }
+	return quotaUseCase, repo
+}
+
+func TestQuotaCheckAndRecord(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	pedant := &conf.Pedant{Quota: &conf.Quota{User: &conf.Limit{RequestsPerMinute: 10, Budgets: []*conf.Budget{{Daily: 100}}}}}
+	quotaUseCase, repo := newTestQuotaUseCase(pedant, &now)
+	ctx := context.Background()
+	
+	status, err := quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if err != nil || status.DailyRemaining != 100 || status.MonthlyRemaining != -1 || status.RequestLimit != 10 || status.RequestRemaining != 9 {
+		t.Fatalf("Check() = %+v, %v", status, err)
+	}
+	
+	// 没有用量时不计入
+	quotaUseCase.Record(ctx, "u1", OpenAILLM, 0)
+	quotaUseCase.Record(ctx, "u1", OpenAILLM, 60)
+	status, err = quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if err != nil || status.DailyRemaining != 40 {
+		t.Fatalf("Check() = %+v, %v, want dailyRemaining 40", status, err)
+	}
+	
+	// 其他用户不受影响
+	status, err = quotaUseCase.Check(ctx, "u2", OpenAILLM)
+	if err != nil || status.DailyRemaining != 100 {
+		t.Fatalf("Check(u2) = %+v, %v, want dailyRemaining 100", status, err)
+	}
+	
+	// 超出预算的请求不计入请求次数
+	quotaUseCase.Record(ctx, "u1", OpenAILLM, 50)
+	status, err = quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if !errors.Is(err, errQuotaExceeded) || status.DailyRemaining != 0 || status.Reset != time.Date(2024, 5, 11, 0, 0, 0, 0, time.Local).Unix() {
+		t.Errorf("Check() = %+v, %v, want quota exceeded", status, err)
+	}
+	if count := repo.counters["quota:user:u1:rpm:202405101200"]; count != 2 {
+		t.Errorf("rpm count = %d, want 2", count)
+	}
+	
+	if err = quotaUseCase.CheckBudget(ctx, "u1", OpenAILLM); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("CheckBudget() error = %v, want quota exceeded", err)
+	}
+}
+
+func TestQuotaWindowRollover(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 30, 0, time.Local)
+	pedant := &conf.Pedant{Quota: &conf.Quota{User: &conf.Limit{RequestsPerMinute: 2}}}
+	quotaUseCase, _ := newTestQuotaUseCase(pedant, &now)
+	ctx := context.Background()
+	
+	for i := 0; i < 2; i++ {
+		if _, err := quotaUseCase.Check(ctx, "u1", OpenAILLM); err != nil {
+			t.Fatalf("Check() #%d error = %v", i, err)
+		}
+	}
+	
+	status, err := quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if !errors.Is(err, errRateLimited) || status.RequestRemaining != 0 || status.Reset != time.Date(2024, 5, 10, 12, 1, 0, 0, time.Local).Unix() {
+		t.Fatalf("Check() = %+v, %v, want rate limited until 12:01", status, err)
+	}
+	
+	// 同一分钟内依然限流
+	now = now.Add(29 * time.Second)
+	if _, err = quotaUseCase.Check(ctx, "u1", OpenAILLM); !errors.Is(err, errRateLimited) {
+		t.Errorf("Check() at 12:00:59 error = %v, want rate limited", err)
+	}
+	
+	// 进入下一分钟后重新计数
+	now = now.Add(time.Second)
+	status, err = quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if err != nil || status.RequestRemaining != 1 {
+		t.Errorf("Check() at 12:01 = %+v, %v, want remaining 1", status, err)
+	}
+}
+
+func TestQuotaBudgets(t *testing.T) {
+	tests := []struct {
+		name      string
+		budgets   []*conf.Budget
+		record    map[string]int // 大模型 -> 记录的 tokens
+		llm       string
+		next      time.Duration // 记录后经过的时间
+		wantErr   bool
+		wantDaily int64
+		wantMonth int64
+		wantReset time.Time
+	}{
+		{
+			name:      "超出每日预算",
+			budgets:   []*conf.Budget{{Daily: 100, Monthly: 1000}},
+			record:    map[string]int{OpenAILLM: 100},
+			llm:       OpenAILLM,
+			wantErr:   true,
+			wantDaily: 0,
+			wantMonth: 900,
+			wantReset: time.Date(2024, 5, 11, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:      "第二天恢复每日预算，每月预算继续累计",
+			budgets:   []*conf.Budget{{Daily: 100, Monthly: 1000}},
+			record:    map[string]int{OpenAILLM: 100},
+			llm:       OpenAILLM,
+			next:      24 * time.Hour,
+			wantDaily: 100,
+			wantMonth: 900,
+		},
+		{
+			name:      "超出每月预算",
+			budgets:   []*conf.Budget{{Monthly: 1000}},
+			record:    map[string]int{OpenAILLM: 600, GoogleLLM: 400},
+			llm:       OpenAILLM,
+			next:      24 * time.Hour,
+			wantErr:   true,
+			wantDaily: -1,
+			wantMonth: 0,
+			wantReset: time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:      "下个月恢复每月预算",
+			budgets:   []*conf.Budget{{Monthly: 1000}},
+			record:    map[string]int{OpenAILLM: 1000},
+			llm:       OpenAILLM,
+			next:      22 * 24 * time.Hour,
+			wantDaily: -1,
+			wantMonth: 1000,
+		},
+		{
+			name:      "大模型的预算不限制其他大模型",
+			budgets:   []*conf.Budget{{Llm: GoogleLLM, Daily: 100}},
+			record:    map[string]int{GoogleLLM: 100},
+			llm:       OpenAILLM,
+			wantDaily: -1,
+			wantMonth: -1,
+		},
+		{
+			name:      "返回剩余最少的预算",
+			budgets:   []*conf.Budget{{Daily: 1000}, {Llm: GoogleLLM, Daily: 100}},
+			record:    map[string]int{GoogleLLM: 60, OpenAILLM: 10},
+			llm:       GoogleLLM,
+			wantDaily: 40,
+			wantMonth: -1,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+			pedant := &conf.Pedant{Quota: &conf.Quota{User: &conf.Limit{Budgets: tt.budgets}}}
+			quotaUseCase, _ := newTestQuotaUseCase(pedant, &now)
+			ctx := context.Background()
+			
+			for llm, tokens := range tt.record {
+				quotaUseCase.Record(ctx, "u1", llm, tokens)
+			}
+			now = now.Add(tt.next)
+			
+			status, err := quotaUseCase.Check(ctx, "u1", tt.llm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status.DailyRemaining != tt.wantDaily || status.MonthlyRemaining != tt.wantMonth {
+				t.Errorf("remaining = %d / %d, want %d / %d", status.DailyRemaining, status.MonthlyRemaining, tt.wantDaily, tt.wantMonth)
+			}
+			if tt.wantErr && status.Reset != tt.wantReset.Unix() {
+				t.Errorf("reset = %s, want %s", time.Unix(status.Reset, 0), tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestQuotaTokenLimit(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	pedant := &conf.Pedant{
+		Quota:  &conf.Quota{Token: &conf.Limit{Budgets: []*conf.Budget{{Daily: 1000}}}},
+		Tokens: []*conf.Token{{Name: "web", Quota: &conf.Limit{Budgets: []*conf.Budget{{Daily: 100}}}}},
+	}
+	quotaUseCase, repo := newTestQuotaUseCase(pedant, &now)
+	
+	// pedant.tokens 中的 quota 覆盖 pedant.quota.token，没有配置用户限制时只限制 token
+	ctx := WithIdentity(context.Background(), Identity{Name: "web"})
+	quotaUseCase.Record(ctx, "u1", OpenAILLM, 100)
+	if _, err := quotaUseCase.Check(ctx, "u1", OpenAILLM); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("Check(web) error = %v, want quota exceeded", err)
+	}
+	
+	ctx = WithIdentity(context.Background(), Identity{Name: "cli"})
+	status, err := quotaUseCase.Check(ctx, "u1", OpenAILLM)
+	if err != nil || status.DailyRemaining != 1000 {
+		t.Errorf("Check(cli) = %+v, %v, want dailyRemaining 1000", status, err)
+	}
+	
+	for key := range repo.counters {
+		if !strings.HasPrefix(key, "quota:token:web:") {
+			t.Errorf("unexpected counter %s", key)
+		}
+	}
+}
+
+func TestGenerateFallbackQuota(t *testing.T) {
+	now := time.Now()
+	pedant := &conf.Pedant{
+		Llm:      OpenAILLM,
+		Fallback: []string{DeepSeekLLM, OllamaLLM},
+		Quota:    &conf.Quota{User: &conf.Limit{Budgets: []*conf.Budget{{Llm: DeepSeekLLM, Daily: 100}}}},
+	}
+	quotaUseCase, _ := newTestQuotaUseCase(pedant, &now)
+	
+	openai := &fakeChatProvider{err: &chat.StatusError{StatusCode: 503}}
+	deepseek := &fakeChatProvider{resp: chat.Response{Content: "deepseek"}}
+	ollama := &fakeChatProvider{resp: chat.Response{Content: "ollama", Usage: chat.Usage{TotalTokens: 20}}}
+	repo := &fakeSessionRepo{}
+	sessionUseCase := newTestSessionUseCase(repo, quotaUseCase, pedant, map[string]chat.Provider{OpenAILLM: openai, DeepSeekLLM: deepseek, OllamaLLM: ollama})
+	
+	// deepseek 超出预算，跳过后使用 ollama
+	ctx := context.Background()
+	quotaUseCase.Record(ctx, "u1", DeepSeekLLM, 100)
+	
+	c, err := sessionUseCase.generate(ctx, generateReq{session: Session{Uuid: "s", UserUuid: "u1"}, content: "你好", llm: OpenAILLM}, nil)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	if c.Llm != OllamaLLM || c.AssistantContent != "ollama" || len(deepseek.requests) != 0 || len(openai.requests) != 1 {
+		t.Errorf("context = %+v, deepseek requests = %d", c, len(deepseek.requests))
+	}
+	
+	// 所有备用大模型都被跳过时返回原来的错误
+	sessionUseCase = newTestSessionUseCase(repo, quotaUseCase, &conf.Pedant{Llm: OpenAILLM, Fallback: []string{DeepSeekLLM}, Quota: pedant.Quota},
+		map[string]chat.Provider{OpenAILLM: openai, DeepSeekLLM: deepseek})
+	_, err = sessionUseCase.generate(ctx, generateReq{session: Session{Uuid: "s", UserUuid: "u1"}, content: "你好", llm: OpenAILLM}, nil)
+	
+	var statusErr *chat.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 || len(deepseek.requests) != 0 {
+		t.Errorf("generate() error = %v, deepseek requests = %d, want status 503, 0", err, len(deepseek.requests))
+	}
+}
@@ -0,0 +1,197 @@
+package biz
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 搜索结果中片段的长度 (字符数)
+
+const (
+	snippetLength = 120
+	snippetBefore = 30
+)
+
+// SearchHit 匹配的一轮对话或一次多模态请求，Uuid 为 session_context 或 multi_modal 的 uuid
+
+type SearchHit struct {
+	Kind             string  `json:"kind,omitempty"` // chat / multimodal
+	Uuid             string  `json:"uuid,omitempty"`
+	SessionUuid      string  `json:"sessionUuid,omitempty"`
+	SessionName      string  `json:"sessionName,omitempty"`
+	UserSnippet      string  `json:"userSnippet,omitempty"`
+	AssistantSnippet string  `json:"assistantSnippet,omitempty"`
+	Score            float64 `json:"score,omitempty"` // 全文索引的相关度，使用 like 匹配时为 0
+	CreateTime       int64   `json:"createTime,omitempty"`
+	UserContent      string  `json:"-"`
+	AssistantContent string  `json:"-"`
+}
+
+type SearchFilter struct {
+	UserUuid  string
+	Terms     []string // 所有关键词都需要匹配
+	StartTime int64
+	EndTime   int64
+	Limit     int
+}
+
+// SearchRepo 有全文索引时使用全文索引，否则使用 like 匹配
+
+type SearchRepo interface {
+	SearchSessionContext(ctx context.Context, filter SearchFilter) ([]SearchHit, error)
+	SearchMultiModal(ctx context.Context, filter SearchFilter) ([]SearchHit, error)
+}
+
+type SearchUseCase struct {
+	searchRepo SearchRepo
+	logger     *zap.Logger
+}
+
+func NewSearchUseCase(searchRepo SearchRepo, logger *zap.Logger) *SearchUseCase {
+	return &SearchUseCase{
+		searchRepo: searchRepo,
+		logger:     logger,
+	}
+}
+
+type SearchReq struct {
+	UserUuid  string `json:"userUuid,omitempty" form:"userUuid"`
+	Q         string `json:"q,omitempty" form:"q" validate:"required"`                              // 关键词，空格分隔
+	Kind      string `json:"kind,omitempty" form:"kind" validate:"omitempty,oneof=chat multimodal"` // 为空时搜索对话和多模态
+	StartTime int64  `json:"startTime,omitempty" form:"startTime"`
+	EndTime   int64  `json:"endTime,omitempty" form:"endTime"`
+	Limit     int    `json:"limit,omitempty" form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// Search 搜索用户的对话和多模态记录，token 没有 multimodal 权限时只搜索对话
+
+func (searchUseCase *SearchUseCase) Search(c *gin.Context) {
+	req := SearchReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	terms := strings.Fields(req.Q)
+	if len(terms) == 0 {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": errCode.ParameterFormatErrMsg})
+		return
+	}
+	
+	identity, _ := IdentityFromContext(c.Request.Context())
+	multiModal := identity.allow(ScopeMultiModal)
+	if req.Kind == UsageKindMultiModal && !multiModal {
+		c.JSON(403, gin.H{"errCode": errCode.UserPermissionDenyCode, "errMsg": errCode.UserPermissionDenyMsg})
+		return
+	}
+	
+	filter := SearchFilter{
+		UserUuid:  req.UserUuid,
+		Terms:     terms,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Limit:     req.Limit,
+	}
+	
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	
+	var hits []SearchHit
+	if req.Kind != UsageKindMultiModal {
+		chatHits, err := searchUseCase.searchRepo.SearchSessionContext(c.Request.Context(), filter)
+		if err != nil {
+			searchUseCase.logger.Error("搜索对话失败", zap.Error(err))
+			c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+			return
+		}
+		hits = append(hits, chatHits...)
+	}
+	
+	if req.Kind != UsageKindChat && multiModal {
+		multiModalHits, err := searchUseCase.searchRepo.SearchMultiModal(c.Request.Context(), filter)
+		if err != nil {
+			searchUseCase.logger.Error("搜索多模态失败", zap.Error(err))
+			c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+			return
+		}
+		hits = append(hits, multiModalHits...)
+	}
+	
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreateTime > hits[j].CreateTime
+	})
+	
+	if len(hits) > filter.Limit {
+		hits = hits[:filter.Limit]
+	}
+	
+	for i := range hits {
+		hits[i].UserSnippet = snippet(hits[i].UserContent, terms)
+		hits[i].AssistantSnippet = snippet(hits[i].AssistantContent, terms)
+		
+		// 全文索引按分词匹配，原文中可能找不到关键词
+		if hits[i].UserSnippet == "" && hits[i].AssistantSnippet == "" {
+			hits[i].UserSnippet = truncate(hits[i].UserContent, snippetLength)
+		}
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "hits": hits})
+}
+
+// 截取第一个关键词附近的内容，没有关键词时返回空
+
+func snippet(content string, terms []string) string {
+	lower := strings.ToLower(content)
+	index := -1
+	for _, term := range terms {
+		i := strings.Index(lower, strings.ToLower(term))
+		if i >= 0 && (index < 0 || i < index) {
+			index = i
+		}
+	}
+	
+	if index < 0 {
+		return ""
+	}
+	
+	// 大小写转换可能改变字节长度，转换前后长度不同时从头截取
+	if len(lower) != len(content) {
+		index = 0
+	}
+	
+	runes := []rune(content)
+	start := max(utf8.RuneCountInString(content[:index])-snippetBefore, 0)
+	end := min(start+snippetLength, len(runes))
+	
+	s := string(runes[start:end])
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(runes) {
+		s += "…"
+	}
+	return s
+}
+
+func truncate(content string, length int) string {
+	runes := []rune(content)
+	if len(runes) <= length {
+		return content
+	}
+	return string(runes[:length]) + "…"
+}
@@ -2,23 +2,36 @@ package biz
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/qx66/pedant/internal/biz/common"
 	"github.com/qx66/pedant/internal/conf"
-	"github.com/qx66/pedant/pkg/baiduCloud"
-	"github.com/qx66/pedant/pkg/gemini"
-	"github.com/qx66/pedant/pkg/openai"
+	"github.com/qx66/pedant/pkg/chat"
 	"github.com/startopsz/rule/pkg/response/errCode"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Session struct {
-	Uuid       string `json:"uuid,omitempty"`
-	UserUuid   string `json:"userUuid,omitempty"`
-	Name       string `json:"name,omitempty"` // Subject
-	CreateTime int64  `json:"createTime,omitempty"`
+	Uuid              string `json:"uuid,omitempty"`
+	UserUuid          string `json:"userUuid,omitempty"`
+	Name              string `json:"name,omitempty"`         // Subject
+	Llm               string `json:"llm,omitempty"`          // 会话使用的大模型，为空时使用 pedant.llm
+	Model             string `json:"model,omitempty"`        // 会话使用的模型，为空时使用大模型的默认模型
+	SystemPrompt      string `json:"systemPrompt,omitempty"` // 会话的系统指令，优先于 persona
+	PersonaUuid       string `json:"personaUuid,omitempty"`  // 引用保存的 persona
+	Summary           string `json:"summary,omitempty"`      // 超出上下文窗口的历史摘要
+	SummaryTime       int64  `json:"summaryTime,omitempty"`  // 摘要包含的最后一轮对话的时间
+	SummaryUuid       string `json:"summaryUuid,omitempty"`  // 摘要包含的最后一轮对话的 uuid，同一秒内的对话按 uuid 排序
+	Archived          bool   `json:"archived,omitempty"`     // 归档的会话默认不在列表中返回
+	Pinned            bool   `json:"pinned,omitempty"`
+	ParentUuid        string `json:"parentUuid,omitempty"`        // 分支的来源会话
+	ParentContextUuid string `json:"parentContextUuid,omitempty"` // 从来源会话的该轮对话分支
+	CreateTime        int64  `json:"createTime,omitempty"`
+	DeleteTime        int64  `json:"deleteTime,omitempty"` // 删除时间，0 表示未删除
 }
 
 func (session Session) TableName() string {
@@ -26,67 +39,105 @@ func (session Session) TableName() string {
 }
 
 type Context struct {
-	Uuid             string `json:"uuid,omitempty"`
-	SessionUuid      string `json:"sessionUuid,omitempty"`
-	UserContent      string `json:"userContent,omitempty"`
-	AssistantContent string `json:"assistantContent,omitempty"`
-	PromptTokens     int    `json:"promptTokens,omitempty"`
-	CompletionTokens int    `json:"completionTokens,omitempty"`
-	TotalTokens      int    `json:"totalTokens,omitempty"`
-	Llm              string `json:"llm,omitempty"`
-	CreateTime       int64  `json:"createTime,omitempty"`
+	Uuid             string       `json:"uuid,omitempty"`
+	SessionUuid      string       `json:"sessionUuid,omitempty"`
+	UserContent      string       `json:"userContent,omitempty"`
+	AssistantContent string       `json:"assistantContent,omitempty"`
+	PromptTokens     int          `json:"promptTokens,omitempty"`
+	CompletionTokens int          `json:"completionTokens,omitempty"`
+	TotalTokens      int          `json:"totalTokens,omitempty"`
+	FinishReason     string       `json:"finishReason,omitempty"` // 大模型停止生成的原因，原样保存各厂商的取值
+	Llm              string       `json:"llm,omitempty"`
+	Model            string       `json:"model,omitempty"`
+	Options          chat.Options `json:"options,omitempty" gorm:"serializer:json"` // 生成时使用的采样参数，用于复现结果
+	Version          int          `json:"version,omitempty" gorm:"default:1"`       // 重新生成或编辑的次数 + 1，历史版本保存在 session_context_version
+	Source           string       `json:"source,omitempty"`                         // 为空时是生成的对话，import 为导入的对话，fork 为分支复制的对话，都不计入用量
+	CreateTime       int64        `json:"createTime,omitempty"`
 }
 
+// 导入的对话没有调用大模型，分支复制的对话已经在原会话中计入用量，保留原有的模型和 tokens 用于展示
+
+const (
+	ContextSourceImport = "import"
+	ContextSourceFork   = "fork"
+)
+
 func (context Context) TableName() string {
 	return "session_context"
 }
 
+const (
+	defaultSystemPrompt = "你是一个聪明的小助理"
+)
+
+var (
+	errUnSupportLLM = errors.New("UnSupport LLM")
+)
+
 type SessionRepo interface {
 	CreateSession(ctx context.Context, session Session) error
-	ListSession(ctx context.Context, userUuid string) ([]Session, error)
+	CreateSessionWithContext(ctx context.Context, session Session, contexts []Context) error
+	ListChildSession(ctx context.Context, userUuid string, parentUuids []string) ([]Session, error)
+	ListSession(ctx context.Context, filter SessionFilter, page Page) ([]Session, error)
+	ListAllSession(ctx context.Context, userUuid string) ([]Session, error)
+	UpdateSession(ctx context.Context, session Session) error
+	ArchiveSession(ctx context.Context, uuid, userUuid string, archived bool) error
+	PinSession(ctx context.Context, uuid, userUuid string, pinned bool) error
 	DeleteSession(ctx context.Context, uuid, userUuid string) error
 	ExistsSession(ctx context.Context, uuid, userUuid string) (bool, error)
-	GetSessionContext(ctx context.Context, sessionUuid string) ([]Context, error)
+	GetSession(ctx context.Context, uuid, userUuid string) (Session, error)
+	UpdateSessionSummary(ctx context.Context, uuid, summary string, summaryTime int64, summaryUuid string) error
+	GetSessionContext(ctx context.Context, sessionUuid string, page Page) ([]Context, error)
+	GetAllSessionContext(ctx context.Context, sessionUuid string) ([]Context, error)
+	GetRecentSessionContext(ctx context.Context, sessionUuid string, limit int) ([]Context, error)
+	GetLastSessionContext(ctx context.Context, sessionUuid string) (Context, error)
+	GetSessionContextByUuid(ctx context.Context, sessionUuid, uuid string) (Context, error)
 	InsertSessionContext(ctx context.Context, c Context) error
+	ReplaceSessionContext(ctx context.Context, c Context, versions []ContextVersion) error
+	ListContextVersion(ctx context.Context, sessionUuid, contextUuid string) ([]ContextVersion, error)
 }
 
 type SessionUseCase struct {
 	sessionRepo    SessionRepo
+	personaRepo    PersonaRepo
 	localCacheRepo LocalCacheRepo
+	chatRegistry   *chat.Registry
+	quotaUseCase   *QuotaUseCase
 	pedant         *conf.Pedant
 	llm            *conf.Llm
+	upgrader       *websocket.Upgrader
 	logger         *zap.Logger
 }
 
-func NewSessionUseCase(sessionRepo SessionRepo, localCacheRepo LocalCacheRepo, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *SessionUseCase {
-	switch pedant.Llm {
-	case OpenAILLM:
-		if llm.Openai.ApiKey == "" {
-			panic("配置使用openai大模型语言，但未配置apikey")
-		}
-	case GoogleLLM:
-		if llm.Gemini.ApiKey == "" {
-			panic("配置使用google大模型语言，但未配置apikey")
-		}
-	case BaiduCloudLLM:
-		if llm.Qianfan.ApiKey == "" || llm.Qianfan.SecretKey == "" {
-			panic("配置使用百度云大模型语言，但未配置apikey/secretKey")
-		}
-	default:
-		panic("配置使用未知的大模型语言")
+func NewSessionUseCase(sessionRepo SessionRepo, personaRepo PersonaRepo, localCacheRepo LocalCacheRepo, chatRegistry *chat.Registry, quotaUseCase *QuotaUseCase, pedant *conf.Pedant, llm *conf.Llm, logger *zap.Logger) *SessionUseCase {
+	if _, ok := chatRegistry.Get(pedant.Llm); !ok {
+		panic("配置使用未知的大模型语言，或未配置该大模型的apikey")
 	}
 	
 	return &SessionUseCase{
 		sessionRepo:    sessionRepo,
+		personaRepo:    personaRepo,
 		localCacheRepo: localCacheRepo,
+		chatRegistry:   chatRegistry,
+		quotaUseCase:   quotaUseCase,
 		llm:            llm,
 		pedant:         pedant,
+		upgrader:       newUpgrader(pedant.GetWebSocket()),
 		logger:         logger,
 	}
 }
 
+type SessionFilter struct {
+	UserUuid string
+	Archived bool
+	Pinned   bool // 为 true 时只返回置顶的会话
+}
+
 type ListSessionReq struct {
-	UserUuid string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Archived bool   `json:"archived,omitempty" form:"archived"` // 为 true 时只返回归档的会话
+	Pinned   bool   `json:"pinned,omitempty" form:"pinned"`     // 为 true 时只返回置顶的会话
+	PageReq
 }
 
 func (sessionUseCase *SessionUseCase) ListSession(c *gin.Context) {
@@ -96,19 +147,36 @@ func (sessionUseCase *SessionUseCase) ListSession(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	page, err := req.page(OrderAsc)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
 	//
-	sessions, err := sessionUseCase.sessionRepo.ListSession(c.Request.Context(), req.UserUuid)
+	filter := SessionFilter{UserUuid: req.UserUuid, Archived: req.Archived, Pinned: req.Pinned}
+	sessions, err := sessionUseCase.sessionRepo.ListSession(c.Request.Context(), filter, page)
 	if err != nil {
 		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "sessions": sessions})
+	sessions, nextPageToken := nextPage(sessions, page, func(session Session) (int64, string) { return session.CreateTime, session.Uuid })
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "sessions": sessions, "nextPageToken": nextPageToken})
 }
 
 type CreateSessionReq struct {
-	UserUuid string `json:"userUuid,omitempty"  validate:"required"`
-	Name     string `json:"name,omitempty"  validate:"required"`
+	UserUuid     string `json:"userUuid,omitempty"`
+	Name         string `json:"name,omitempty"  validate:"required"`
+	Llm          string `json:"llm,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	PersonaUuid  string `json:"personaUuid,omitempty"`
 }
 
 func (sessionUseCase *SessionUseCase) CreateSession(c *gin.Context) {
@@ -118,13 +186,31 @@ func (sessionUseCase *SessionUseCase) CreateSession(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
+		return
+	}
+	
+	if !sessionUseCase.existsPersona(c, req.PersonaUuid, req.UserUuid) {
+		return
+	}
+	
 	sessionUuid := uuid.NewString()
 	//
 	err = sessionUseCase.sessionRepo.CreateSession(c.Request.Context(), Session{
-		Uuid:       sessionUuid,
-		UserUuid:   req.UserUuid,
-		Name:       req.Name,
-		CreateTime: time.Now().Unix(),
+		Uuid:         sessionUuid,
+		UserUuid:     req.UserUuid,
+		Name:         req.Name,
+		Llm:          req.Llm,
+		Model:        req.Model,
+		SystemPrompt: req.SystemPrompt,
+		PersonaUuid:  req.PersonaUuid,
+		CreateTime:   time.Now().Unix(),
 	})
 	
 	if err != nil {
@@ -135,8 +221,173 @@ func (sessionUseCase *SessionUseCase) CreateSession(c *gin.Context) {
 	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "uuid": sessionUuid})
 }
 
+// 修改会话名称或大模型，字段为空表示不修改
+
+type UpdateSessionReq struct {
+	UserUuid     string `json:"userUuid,omitempty"`
+	Uuid         string `json:"uuid,omitempty"  validate:"required"`
+	Name         string `json:"name,omitempty"`
+	Llm          string `json:"llm,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	PersonaUuid  string `json:"personaUuid,omitempty"`
+}
+
+func (sessionUseCase *SessionUseCase) UpdateSession(c *gin.Context) {
+	req := UpdateSessionReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
+		return
+	}
+	
+	if !sessionUseCase.existsPersona(c, req.PersonaUuid, req.UserUuid) {
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	if req.Name != "" {
+		session.Name = req.Name
+	}
+	
+	if req.SystemPrompt != "" {
+		session.SystemPrompt = req.SystemPrompt
+	}
+	
+	if req.PersonaUuid != "" {
+		session.PersonaUuid = req.PersonaUuid
+	}
+	
+	// 切换大模型时，原来的模型名称不再适用
+	if req.Llm != "" {
+		session.Llm = req.Llm
+		session.Model = req.Model
+	} else if req.Model != "" {
+		session.Model = req.Model
+	}
+	
+	err = sessionUseCase.sessionRepo.UpdateSession(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "session": session})
+}
+
+type RenameSessionReq struct {
+	UserUuid string `json:"userUuid,omitempty"`
+	Uuid     string `json:"uuid,omitempty" validate:"required"`
+	Name     string `json:"name,omitempty" validate:"required"`
+}
+
+func (sessionUseCase *SessionUseCase) RenameSession(c *gin.Context) {
+	req := RenameSessionReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	session.Name = req.Name
+	err = sessionUseCase.sessionRepo.UpdateSession(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "session": session})
+}
+
+type SessionReq struct {
+	UserUuid string `json:"userUuid,omitempty"`
+	Uuid     string `json:"uuid,omitempty" validate:"required"`
+}
+
+func (sessionUseCase *SessionUseCase) ArchiveSession(c *gin.Context) {
+	sessionUseCase.setSessionState(c, func(ctx context.Context, session *Session) error {
+		session.Archived = true
+		return sessionUseCase.sessionRepo.ArchiveSession(ctx, session.Uuid, session.UserUuid, true)
+	})
+}
+
+func (sessionUseCase *SessionUseCase) UnarchiveSession(c *gin.Context) {
+	sessionUseCase.setSessionState(c, func(ctx context.Context, session *Session) error {
+		session.Archived = false
+		return sessionUseCase.sessionRepo.ArchiveSession(ctx, session.Uuid, session.UserUuid, false)
+	})
+}
+
+func (sessionUseCase *SessionUseCase) PinSession(c *gin.Context) {
+	sessionUseCase.setSessionState(c, func(ctx context.Context, session *Session) error {
+		session.Pinned = true
+		return sessionUseCase.sessionRepo.PinSession(ctx, session.Uuid, session.UserUuid, true)
+	})
+}
+
+func (sessionUseCase *SessionUseCase) UnpinSession(c *gin.Context) {
+	sessionUseCase.setSessionState(c, func(ctx context.Context, session *Session) error {
+		session.Pinned = false
+		return sessionUseCase.sessionRepo.PinSession(ctx, session.Uuid, session.UserUuid, false)
+	})
+}
+
+// 归档、置顶等只修改会话状态的操作，返回修改后的会话
+
+func (sessionUseCase *SessionUseCase) setSessionState(c *gin.Context, update func(ctx context.Context, session *Session) error) {
+	req := SessionReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	err = update(c.Request.Context(), &session)
+	if err != nil {
+		sessionUseCase.logger.Error("修改会话状态失败", zap.String("uuid", req.Uuid), zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "session": session})
+}
+
+// 删除会话时在同一个事务中软删除会话和上下文
+// 上下文的用量已经计费，保留数据用于用量报表
+
 type DelSessionReq struct {
-	UserUuid string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
 	Uuid     string `json:"uuid" form:"uuid"  validate:"required"`
 }
 
@@ -147,6 +398,11 @@ func (sessionUseCase *SessionUseCase) DelSession(c *gin.Context) {
 		return
 	}
 	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
 	err = sessionUseCase.sessionRepo.DeleteSession(c.Request.Context(), req.Uuid, req.UserUuid)
 	if err != nil {
 		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
@@ -156,8 +412,9 @@ func (sessionUseCase *SessionUseCase) DelSession(c *gin.Context) {
 }
 
 type ListSessionContextReq struct {
-	UserUuid    string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid    string `json:"userUuid,omitempty" form:"userUuid"`
 	SessionUuid string `json:"sessionUuid,omitempty" form:"sessionUuid" validate:"required"`
+	PageReq
 }
 
 func (sessionUseCase *SessionUseCase) ListSessionContext(c *gin.Context) {
@@ -166,6 +423,18 @@ func (sessionUseCase *SessionUseCase) ListSessionContext(c *gin.Context) {
 	if err != nil {
 		return
 	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	page, err := req.page(OrderAsc)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
 	//
 	e, err := sessionUseCase.sessionRepo.ExistsSession(c.Request.Context(), req.SessionUuid, req.UserUuid)
 	if err != nil {
@@ -179,20 +448,25 @@ func (sessionUseCase *SessionUseCase) ListSessionContext(c *gin.Context) {
 	}
 	
 	//
-	contexts, err := sessionUseCase.sessionRepo.GetSessionContext(c.Request.Context(), req.SessionUuid)
+	contexts, err := sessionUseCase.sessionRepo.GetSessionContext(c.Request.Context(), req.SessionUuid, page)
 	if err != nil {
 		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "contexts": contexts})
+	contexts, nextPageToken := nextPage(contexts, page, func(context Context) (int64, string) { return context.CreateTime, context.Uuid })
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "contexts": contexts, "nextPageToken": nextPageToken})
 	return
 }
 
 type CreateSessionContextReq struct {
-	UserUuid    string `json:"userUuid,omitempty" form:"userUuid"  validate:"required"`
+	UserUuid    string `json:"userUuid,omitempty" form:"userUuid"`
 	SessionUuid string `json:"sessionUuid,omitempty" form:"sessionUuid" validate:"required"`
 	Content     string `json:"content,omitempty" form:"content" validate:"required"`
+	Stream      bool   `json:"stream,omitempty" form:"stream"` // 为 true 或 Accept: text/event-stream 时使用 SSE 流式返回
+	Llm         string `json:"llm,omitempty" form:"llm"`       // 为空时使用会话的大模型
+	Model       string `json:"model,omitempty" form:"model"`   // 为空时使用会话的模型
+	chat.Options
 }
 
 func (sessionUseCase *SessionUseCase) CreateSessionContext(c *gin.Context) {
@@ -202,248 +476,322 @@ func (sessionUseCase *SessionUseCase) CreateSessionContext(c *gin.Context) {
 		return
 	}
 	
-	//
-	e, err := sessionUseCase.sessionRepo.ExistsSession(c.Request.Context(), req.SessionUuid, req.UserUuid)
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
 	if err != nil {
-		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
 		return
 	}
 	
-	if !e {
-		c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
 		return
 	}
 	
 	//
-	contexts, err := sessionUseCase.sessionRepo.GetSessionContext(c.Request.Context(), req.SessionUuid)
-	if err != nil {
-		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+	session, ok := sessionUseCase.getSession(c, req.SessionUuid, req.UserUuid)
+	if !ok {
 		return
 	}
 	
-	//
-	switch sessionUseCase.pedant.Llm {
-	case OpenAILLM:
-		apiKey := sessionUseCase.llm.Openai.ApiKey
-		body := generateOpenAiContext(contexts)
-		
-		body.Messages = append(body.Messages, openai.GptTurbo0301Message{
-			Role:    "user",
-			Content: req.Content,
-		})
-		
-		resp, err := openai.SendChat(body, apiKey)
-		if err != nil {
-			sessionUseCase.logger.Error("请求OpenAi API失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
-		}
-		
-		err = sessionUseCase.sessionRepo.InsertSessionContext(c.Request.Context(), Context{
-			Uuid:             uuid.NewString(),
-			SessionUuid:      req.SessionUuid,
-			UserContent:      req.Content,
-			AssistantContent: resp.Choices[0].Message.Content,
-			PromptTokens:     int(resp.Usage.PromptTokens),
-			CompletionTokens: int(resp.Usage.CompletionTokens),
-			TotalTokens:      int(resp.Usage.TotalTokens),
-			Llm:              OpenAILLM,
-			CreateTime:       time.Now().Unix(),
-		})
-		if err != nil {
-			sessionUseCase.logger.Error("插入数据库失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+	gReq := generateReq{session: session, content: req.Content, options: req.Options}
+	sessionUseCase.respondGenerate(c, gReq, req.Llm, req.Model, req.Stream)
+}
+
+// SSE 流式返回
+// event: delta 为增量内容，event: done 为最终保存的上下文，event: error 为请求失败
+
+func (sessionUseCase *SessionUseCase) streamSessionContext(c *gin.Context, req generateReq) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	
+	sessionContext, err := sessionUseCase.generate(c.Request.Context(), req, func(delta string) error {
+		// 客户端断开后停止转发，已生成的内容依然会被保存
+		if err := c.Request.Context().Err(); err != nil {
+			return err
 		}
 		
-		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": resp.Choices[0].Message.Content})
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	})
+	
+	if err != nil {
+		c.SSEvent("error", gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg, "uuid": sessionContext.Uuid})
+		c.Writer.Flush()
 		return
+	}
 	
-	case GoogleLLM:
-		apiKey := sessionUseCase.llm.Gemini.ApiKey
-		k := gemini.ApiKey(apiKey)
-		
-		his := generateGeminiContext(contexts)
-		resp, err := k.Chat(his, req.Content)
+	c.SSEvent("done", gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "context": sessionContext})
+	c.Writer.Flush()
+}
+
+// 一轮对话的参数
+
+type generateReq struct {
+	session     Session
+	content     string
+	llm         string
+	model       string
+	options     chat.Options
+	replaceUuid string // 重新生成或编辑时被替换的上下文uuid，为空表示新增一轮对话，编辑时之后的对话从会话中移除
+}
+
+// 一轮对话: 加载历史上下文 -> 请求大模型 -> 保存上下文
+// onDelta 不为空时使用流式接口，流式请求中断 (客户端断开或上游报错) 时已生成的部分内容也会保存，
+// 此时返回的 Context 与 error 同时不为空
+
+func (sessionUseCase *SessionUseCase) generate(ctx context.Context, req generateReq, onDelta func(delta string) error) (Context, error) {
+	provider, ok := sessionUseCase.chatRegistry.Get(req.llm)
+	if !ok {
+		return Context{}, errUnSupportLLM
+	}
+	
+	session := req.session
+	summaryEnabled := sessionUseCase.pedant.GetContextWindow().GetSummary()
+	if summaryEnabled {
+		// 摘要可能在其他请求中更新过，重新查询
+		s, err := sessionUseCase.sessionRepo.GetSession(ctx, session.Uuid, session.UserUuid)
 		if err != nil {
-			sessionUseCase.logger.Error("请求Google Gemini Api失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+			sessionUseCase.logger.Error("查询数据库失败", zap.Error(err))
+			return Context{}, err
 		}
-		
-		err = sessionUseCase.sessionRepo.InsertSessionContext(c.Request.Context(), Context{
-			Uuid:             uuid.NewString(),
-			SessionUuid:      req.SessionUuid,
-			UserContent:      req.Content,
-			AssistantContent: resp.Candidates[0].Content.Parts[0].Text,
-			//PromptTokens:     resp.Usage.PromptTokens,
-			//CompletionTokens: resp.Usage.CompletionTokens,
-			//TotalTokens:      resp.Usage.TotalTokens,
-			Llm:        GoogleLLM,
-			CreateTime: time.Now().Unix(),
-		})
-		if err != nil {
-			sessionUseCase.logger.Error("插入数据库失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+		session = s
+	}
+	
+	contexts, replaced, following, err := sessionUseCase.historyContexts(ctx, session.Uuid, req.replaceUuid)
+	if err != nil {
+		sessionUseCase.logger.Error("查询数据库失败", zap.Error(err))
+		return Context{}, err
+	}
+	
+	system := generateSystemPrompt(sessionUseCase.systemPrompt(ctx, session), session.Summary)
+	userMessage := chat.Message{Role: chat.RoleUser, Content: req.content}
+	budget := sessionUseCase.contextMaxTokens() - chat.EstimateTokens(system) - chat.EstimateMessageTokens(userMessage)
+	
+	// 可重试的错误 (网络错误、429、5xx) 依次切换到 pedant.fallback 中的大模型
+	var resp chat.Response
+	var dropped []Context
+	llm, model := req.llm, req.model
+	for i, name := range sessionUseCase.fallbackChain(req.llm) {
+		p, _ := sessionUseCase.chatRegistry.Get(name)
+		m := req.model
+		if i > 0 {
+			// 指定的模型只适用于原来的大模型
+			m = ""
+			if e := chat.ValidateOptions(p.Limits(), req.options); e != nil {
+				sessionUseCase.logger.Warn("采样参数不适用于备用大模型，跳过", zap.String("llm", name), zap.Error(e))
+				continue
+			}
+			
+			// 额度按大模型限制，请求前只检查了原来的大模型
+			if e := sessionUseCase.quotaUseCase.CheckBudget(ctx, session.UserUuid, name); e != nil {
+				sessionUseCase.logger.Warn("备用大模型超出额度，跳过", zap.String("llm", name), zap.Error(e))
+				continue
+			}
 		}
 		
-		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": resp.Candidates[0].Content.Parts[0].Text})
-		return
-	
-	case BaiduCloudLLM:
-		//sessionUseCase.llm.Qianfan
-		token, err := sessionUseCase.GetAccessToken(c.Request.Context())
-		if err != nil {
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+		chatReq := chat.Request{
+			Model:    m,
+			System:   system,
+			Messages: []chat.Message{userMessage},
+			Options:  req.options,
 		}
 		
-		body := generateQianFanContext(contexts, req.Content)
+		resp, dropped, err = sessionUseCase.chatWithinWindow(ctx, p, chatReq, contexts, budget, onDelta)
+		provider, llm, model = p, name, m
 		
-		resp, err := baiduCloud.SendERNIEBotTurbo(token, body)
-		if err != nil {
-			sessionUseCase.logger.Error("请求百度云API失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+		// 已经输出了内容、客户端取消或不可重试的错误时不再切换
+		if err == nil || resp.Content != "" || ctx.Err() != nil || !chat.IsRetryable(err) {
+			break
 		}
-		
-		err = sessionUseCase.sessionRepo.InsertSessionContext(c.Request.Context(), Context{
-			Uuid:             uuid.NewString(),
-			SessionUuid:      req.SessionUuid,
-			UserContent:      req.Content,
-			AssistantContent: resp.Result,
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-			Llm:              BaiduCloudLLM,
-			CreateTime:       time.Now().Unix(),
-		})
-		if err != nil {
-			sessionUseCase.logger.Error("插入数据库失败", zap.Error(err))
-			c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
-			return
+		sessionUseCase.logger.Warn("请求大模型API失败，切换到备用大模型", zap.String("llm", name), zap.Error(err))
+	}
+	
+	if err != nil {
+		sessionUseCase.logger.Error("请求大模型API失败", zap.String("llm", llm), zap.String("model", model), zap.Error(err))
+		if resp.Content == "" {
+			return Context{}, err
 		}
-		
-		c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": resp.Result})
-		return
+	}
 	
-	default:
-		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": "UnSupport LLM"})
-		return
+	if summaryEnabled && len(dropped) > 0 {
+		go sessionUseCase.updateSummary(context.WithoutCancel(ctx), provider, model, session, dropped)
 	}
-}
-
-func (sessionUseCase *SessionUseCase) GetAccessToken(ctx context.Context) (string, error) {
-	accessTokenByte, err := sessionUseCase.localCacheRepo.GetLocalCache(accessTokenKey)
 	
-	if string(accessTokenByte) != "" && err == nil {
-		return string(accessTokenByte), nil
+	// 未指定模型时记录大模型实际返回的模型名称
+	if resp.Model != "" {
+		model = resp.Model
 	}
 	
-	// 获取失败，则通过 API 重新获取Token
-	if err != nil {
-		sessionUseCase.logger.Error("从LocalCache中获取AccessToken失败", zap.Error(err))
+	sessionContext := Context{
+		Uuid:             uuid.NewString(),
+		SessionUuid:      req.session.Uuid,
+		UserContent:      req.content,
+		AssistantContent: resp.Content,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+		FinishReason:     resp.FinishReason,
+		Llm:              llm,
+		Model:            model,
+		Options:          req.options,
+		Version:          1,
+		CreateTime:       time.Now().Unix(),
 	}
 	
-	// 通过 API 获取Token
-	accessToken, err := baiduCloud.GetQianFanAccessToken(sessionUseCase.llm.Qianfan.ApiKey, sessionUseCase.llm.Qianfan.SecretKey)
-	if err != nil {
-		sessionUseCase.logger.Error("调用百度千帆API获取AccessToken失败", zap.Error(err))
-		return "", err
+	// 请求可能已经被客户端取消，保存时不继承取消信号
+	// 替换时原来的对话保存为历史版本，uuid 不变
+	var e error
+	if req.replaceUuid != "" {
+		sessionContext.Uuid = req.replaceUuid
+		sessionContext.Version = max(replaced.Version, 1) + 1
+		e = sessionUseCase.sessionRepo.ReplaceSessionContext(context.WithoutCancel(ctx), sessionContext, contextVersions(replaced, following))
+	} else {
+		e = sessionUseCase.sessionRepo.InsertSessionContext(context.WithoutCancel(ctx), sessionContext)
+	}
+	
+	if e != nil {
+		sessionUseCase.logger.Error("保存上下文到数据库失败", zap.Error(e))
+		return Context{}, e
 	}
 	
-	err = sessionUseCase.localCacheRepo.SetLocalCache(accessTokenKey, []byte(accessToken.AccessToken))
+	// 只有保存成功的对话计入额度，与用量报表一致
+	// 部分大模型 (比如流式输出的 ollama) 不返回用量，按估算值计入
+	tokens := sessionContext.TotalTokens
+	if tokens == 0 {
+		tokens = chat.EstimateTokens(req.content) + chat.EstimateTokens(resp.Content)
+	}
+	sessionUseCase.quotaUseCase.Record(context.WithoutCancel(ctx), session.UserUuid, llm, tokens)
+	
+	return sessionContext, err
+}
+
+// 查询会话，不存在或查询失败时直接返回错误响应
+
+func (sessionUseCase *SessionUseCase) getSession(c *gin.Context, uuid, userUuid string) (Session, bool) {
+	session, err := sessionUseCase.sessionRepo.GetSession(c.Request.Context(), uuid, userUuid)
 	if err != nil {
-		sessionUseCase.logger.Error("设置LocalCache的AccessToken失败", zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+			return session, false
+		}
+		
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return session, false
 	}
 	
-	return accessToken.AccessToken, nil
+	return session, true
 }
 
-func (sessionUseCase *SessionUseCase) GetAccessTokenByLocalCache(ctx context.Context) ([]byte, error) {
-	return sessionUseCase.localCacheRepo.GetLocalCache(accessTokenKey)
+// 会话的系统指令: 会话配置 > persona > 默认系统指令
+// 系统指令通过 chat.Request.System 交由各 Provider 使用厂商原生的方式设置 (openai system 消息、Gemini systemInstruction、ERNIE system 字段)
+
+func (sessionUseCase *SessionUseCase) systemPrompt(ctx context.Context, session Session) string {
+	if session.SystemPrompt != "" {
+		return session.SystemPrompt
+	}
+	
+	if session.PersonaUuid != "" {
+		persona, err := sessionUseCase.personaRepo.GetPersona(ctx, session.PersonaUuid, session.UserUuid)
+		if err == nil && persona.SystemPrompt != "" {
+			return persona.SystemPrompt
+		}
+		
+		// persona 被删除时使用默认系统指令
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			sessionUseCase.logger.Error("查询 persona 失败", zap.String("personaUuid", session.PersonaUuid), zap.Error(err))
+		}
+	}
+	
+	return defaultSystemPrompt
 }
 
-func generateOpenAiContext(contexts []Context) openai.GptTurbo0301 {
-	var messages []openai.GptTurbo0301Message
-	messages = append(messages, openai.GptTurbo0301Message{
-		Role:    openai.ChatRoleSystem,
-		Content: "你是一个聪明的小助理",
-	})
+// 为空表示不引用 persona，不存在或查询失败时直接返回错误响应
+
+func (sessionUseCase *SessionUseCase) existsPersona(c *gin.Context, personaUuid, userUuid string) bool {
+	if personaUuid == "" {
+		return true
+	}
 	
-	for _, c := range contexts {
-		if c.Llm == OpenAILLM {
-			messages = append(messages, openai.GptTurbo0301Message{
-				Role:    openai.ChatRoleUser,
-				Content: c.UserContent,
-			})
-			
-			messages = append(messages, openai.GptTurbo0301Message{
-				Role:    openai.ChatRoleAssistant,
-				Content: c.AssistantContent,
-			})
+	_, err := sessionUseCase.personaRepo.GetPersona(c.Request.Context(), personaUuid, userUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+			return false
 		}
+		
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return false
 	}
 	
-	return openai.GptTurbo0301{
-		Model:    openai.ChatModuleGpt35Turbo,
-		Messages: messages,
+	return true
+}
+
+// 为空表示使用默认大模型
+
+func (sessionUseCase *SessionUseCase) supportLLM(llm string) bool {
+	if llm == "" {
+		return true
 	}
+	
+	_, ok := sessionUseCase.chatRegistry.Get(llm)
+	return ok
 }
 
-func generateGeminiContext(contexts []Context) []gemini.Content {
-	var cs []gemini.Content
+// 校验采样参数是否在大模型支持的范围内
+
+func (sessionUseCase *SessionUseCase) validateOptions(llm string, opts chat.Options) error {
+	provider, ok := sessionUseCase.chatRegistry.Get(llm)
+	if !ok {
+		return errUnSupportLLM
+	}
 	
-	for _, c := range contexts {
-		if c.Llm == GoogleLLM {
-			cs = append(cs, gemini.Content{
-				Role: gemini.ChatRoleUser,
-				Parts: []interface{}{
-					gemini.ContentText{
-						Text: c.UserContent,
-					},
-				},
-			})
-			
-			cs = append(cs, gemini.Content{
-				Role: gemini.ChatRoleModel,
-				Parts: []interface{}{
-					gemini.ContentText{
-						Text: c.AssistantContent,
-					},
-				},
-			})
-		}
+	return chat.ValidateOptions(provider.Limits(), opts)
+}
+
+// 选择大模型: 请求参数 > 会话配置 > pedant.llm
+// 只指定了大模型没有指定模型时使用该大模型的默认模型
+
+func (sessionUseCase *SessionUseCase) chooseModel(session Session, llm, model string) (string, string) {
+	if llm != "" {
+		return llm, model
+	}
+	
+	llm = session.Llm
+	if llm == "" {
+		llm = sessionUseCase.pedant.Llm
 	}
 	
-	return cs
+	if model == "" {
+		model = session.Model
+	}
+	
+	return llm, model
 }
 
-func generateQianFanContext(contexts []Context, text string) baiduCloud.ERNIEBotTurboReq {
-	var messages []baiduCloud.ERNIEBotTurboMessage
+// 历史上下文转换为通用消息格式，与生成该轮对话的大模型无关，
+// 由各 Provider 转换为自己的角色 (如 Gemini 的 model 对应 assistant)，因此会话中途切换大模型不会丢失上下文
+// 缺少提问或回答的轮次会被跳过，保证 user / assistant 交替出现 (ERNIE、Gemini 要求严格交替)
+
+func generateChatContext(contexts []Context) []chat.Message {
+	var messages []chat.Message
 	
 	for _, c := range contexts {
-		if c.Llm == BaiduCloudLLM {
-			messages = append(messages, baiduCloud.ERNIEBotTurboMessage{
-				Role:    baiduCloud.ChatRoleUser,
-				Content: c.UserContent,
-			})
-			
-			messages = append(messages, baiduCloud.ERNIEBotTurboMessage{
-				Role:    baiduCloud.ChatRoleAssistant,
-				Content: c.AssistantContent,
-			})
+		if c.UserContent == "" || c.AssistantContent == "" {
+			continue
 		}
+		
+		messages = append(messages, chat.Message{
+			Role:    chat.RoleUser,
+			Content: c.UserContent,
+		})
+		
+		messages = append(messages, chat.Message{
+			Role:    chat.RoleAssistant,
+			Content: c.AssistantContent,
+		})
 	}
 	
-	messages = append(messages, baiduCloud.ERNIEBotTurboMessage{
-		Role:    baiduCloud.ChatRoleUser,
-		Content: text,
-	})
-	
-	return baiduCloud.ERNIEBotTurboReq{
-		Messages: messages,
-		Stream:   false,
-	}
+	return messages
 }
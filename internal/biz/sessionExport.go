@@ -0,0 +1,293 @@
+package biz
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"html/template"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 导出格式
+
+const (
+	ExportMarkdown = "markdown"
+	ExportJson     = "json"
+	ExportHtml     = "html"
+)
+
+type sessionExporter struct {
+	ext         string
+	contentType string
+	render      func(w io.Writer, export SessionExport) error
+}
+
+var sessionExporters = map[string]sessionExporter{
+	ExportMarkdown: {ext: ".md", contentType: "text/markdown; charset=utf-8", render: renderMarkdown},
+	ExportJson:     {ext: ".json", contentType: "application/json; charset=utf-8", render: renderJson},
+	ExportHtml:     {ext: ".html", contentType: "text/html; charset=utf-8", render: renderHtml},
+}
+
+// SessionExport 导出的会话和所有对话
+
+type SessionExport struct {
+	Session
+	Contexts   []Context `json:"contexts"`
+	ExportTime int64     `json:"exportTime"`
+}
+
+type ExportSessionReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Uuid     string `json:"uuid,omitempty" form:"uuid" validate:"required"`
+	Format   string `json:"format,omitempty" form:"format" validate:"omitempty,oneof=markdown json html"` // 默认 markdown
+}
+
+// ExportSession 导出单个会话
+
+func (sessionUseCase *SessionUseCase) ExportSession(c *gin.Context) {
+	req := ExportSessionReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	contexts, err := sessionUseCase.sessionRepo.GetAllSessionContext(c.Request.Context(), session.Uuid)
+	if err != nil {
+		sessionUseCase.logger.Error("查询会话上下文失败", zap.String("uuid", session.Uuid), zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	exporter := sessionExporters[exportFormat(req.Format)]
+	c.Header("Content-Type", exporter.contentType)
+	c.Header("Content-Disposition", attachment(exportFilename(session)+exporter.ext))
+	c.Status(200)
+	
+	err = exporter.render(c.Writer, SessionExport{Session: session, Contexts: contexts, ExportTime: time.Now().Unix()})
+	if err != nil {
+		sessionUseCase.logger.Error("导出会话失败", zap.String("uuid", session.Uuid), zap.Error(err))
+	}
+}
+
+type ExportAllSessionReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Format   string `json:"format,omitempty" form:"format" validate:"omitempty,oneof=markdown json html"`
+}
+
+// ExportAllSession 将用户的所有会话 (包括归档的会话) 导出为 zip，每个会话一个文件
+
+func (sessionUseCase *SessionUseCase) ExportAllSession(c *gin.Context) {
+	req := ExportAllSessionReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	sessions, err := sessionUseCase.sessionRepo.ListAllSession(c.Request.Context(), req.UserUuid)
+	if err != nil {
+		sessionUseCase.logger.Error("查询会话失败", zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	exporter := sessionExporters[exportFormat(req.Format)]
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", attachment(fmt.Sprintf("pedant-sessions-%s.zip", time.Now().Format("20060102"))))
+	c.Status(200)
+	
+	// 响应已经开始写入，出错时只能中断
+	zw := zip.NewWriter(c.Writer)
+	for _, session := range sessions {
+		err = sessionUseCase.exportToZip(c, zw, exporter, session)
+		if err != nil {
+			sessionUseCase.logger.Error("导出会话失败", zap.String("uuid", session.Uuid), zap.Error(err))
+			c.Abort()
+			return
+		}
+	}
+	
+	err = zw.Close()
+	if err != nil {
+		sessionUseCase.logger.Error("导出会话失败", zap.Error(err))
+	}
+}
+
+func (sessionUseCase *SessionUseCase) exportToZip(c *gin.Context, zw *zip.Writer, exporter sessionExporter, session Session) error {
+	contexts, err := sessionUseCase.sessionRepo.GetAllSessionContext(c.Request.Context(), session.Uuid)
+	if err != nil {
+		return err
+	}
+	
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     exportFilename(session) + exporter.ext,
+		Method:   zip.Deflate,
+		Modified: time.Unix(session.CreateTime, 0),
+	})
+	if err != nil {
+		return err
+	}
+	
+	return exporter.render(w, SessionExport{Session: session, Contexts: contexts, ExportTime: time.Now().Unix()})
+}
+
+func exportFormat(format string) string {
+	if format == "" {
+		return ExportMarkdown
+	}
+	return format
+}
+
+// 文件名: 会话名称-uuid 前 8 位，去掉文件名中不允许的字符
+
+var unsafeFilename = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f]+`)
+
+func exportFilename(session Session) string {
+	name := strings.TrimSpace(unsafeFilename.ReplaceAllString(session.Name, "_"))
+	if runes := []rune(name); len(runes) > 50 {
+		name = string(runes[:50])
+	}
+	
+	id := session.Uuid
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	
+	if name == "" {
+		return id
+	}
+	return name + "-" + id
+}
+
+// 文件名可能包含中文，同时设置 filename*
+
+func attachment(filename string) string {
+	return fmt.Sprintf("attachment; filename=%q; filename*=UTF-8''%s",
+		strings.Map(func(r rune) rune {
+			if r > 0x7e || r < 0x20 || r == '"' || r == '\\' {
+				return '_'
+			}
+			return r
+		}, filename), url.PathEscape(filename))
+}
+
+func exportTime(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).Format(time.DateTime)
+}
+
+// 每轮对话的元数据: 大模型 / 模型 · tokens · 停止原因
+
+func contextMeta(context Context) string {
+	var meta []string
+	if context.Llm != "" {
+		llm := context.Llm
+		if context.Model != "" {
+			llm += " / " + context.Model
+		}
+		meta = append(meta, llm)
+	}
+	
+	if context.TotalTokens > 0 {
+		meta = append(meta, fmt.Sprintf("tokens %d (输入 %d, 输出 %d)", context.TotalTokens, context.PromptTokens, context.CompletionTokens))
+	}
+	
+	if context.FinishReason != "" {
+		meta = append(meta, context.FinishReason)
+	}
+	return strings.Join(meta, " · ")
+}
+
+func renderJson(w io.Writer, export SessionExport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+func renderMarkdown(w io.Writer, export SessionExport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", export.Name)
+	fmt.Fprintf(&b, "- 会话: `%s`\n", export.Uuid)
+	if export.Llm != "" {
+		fmt.Fprintf(&b, "- 大模型: %s\n", strings.TrimSuffix(export.Llm+" / "+export.Model, " / "))
+	}
+	fmt.Fprintf(&b, "- 创建时间: %s\n", exportTime(export.CreateTime))
+	fmt.Fprintf(&b, "- 导出时间: %s\n", exportTime(export.ExportTime))
+	
+	for i, context := range export.Contexts {
+		fmt.Fprintf(&b, "\n## %d. 用户\n\n", i+1)
+		fmt.Fprintf(&b, "_%s_\n\n%s\n", exportTime(context.CreateTime), context.UserContent)
+		
+		b.WriteString("\n### 助理\n\n")
+		if meta := contextMeta(context); meta != "" {
+			fmt.Fprintf(&b, "_%s_\n\n", meta)
+		}
+		fmt.Fprintf(&b, "%s\n", context.AssistantContent)
+	}
+	
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var exportHtmlTemplate = template.Must(template.New("session").Funcs(template.FuncMap{
+	"time": exportTime,
+	"meta": contextMeta,
+	"inc":  func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body { max-width: 860px; margin: 2em auto; padding: 0 1em; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #222; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1.5em; }
+.info, .meta { color: #888; font-size: 0.85em; }
+.turn { margin-bottom: 1.5em; }
+.message { white-space: pre-wrap; word-wrap: break-word; padding: 0.8em 1em; border-radius: 6px; margin: 0.3em 0; }
+.user { background: #eef4ff; }
+.assistant { background: #f6f6f6; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+<p class="info">会话 {{.Uuid}}{{if .Llm}} · {{.Llm}}{{if .Model}} / {{.Model}}{{end}}{{end}} · 创建于 {{time .CreateTime}} · 导出于 {{time .ExportTime}}</p>
+</header>
+{{range $i, $c := .Contexts}}<section class="turn" id="{{$c.Uuid}}">
+<div class="meta">#{{inc $i}} · {{time $c.CreateTime}}</div>
+<div class="message user">{{$c.UserContent}}</div>
+<div class="message assistant">{{$c.AssistantContent}}</div>
+{{with meta $c}}<div class="meta">{{.}}</div>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+func renderHtml(w io.Writer, export SessionExport) error {
+	return exportHtmlTemplate.Execute(w, export)
+}
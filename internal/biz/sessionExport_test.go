@@ -0,0 +1,89 @@
+package biz
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExportFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		want    string
+	}{
+		{name: "中文名称", session: Session{Name: "你好 世界", Uuid: "0123456789abcdef"}, want: "你好 世界-01234567"},
+		{name: "不允许的字符", session: Session{Name: `a/b\c:d*e?"f"<g>|h` + "\n", Uuid: "0123456789abcdef"}, want: "a_b_c_d_e_f_g_h_-01234567"},
+		{name: "没有名称", session: Session{Name: "  ", Uuid: "0123456789abcdef"}, want: "01234567"},
+		{name: "短 uuid", session: Session{Name: "a", Uuid: "0123"}, want: "a-0123"},
+		{name: "名称超长", session: Session{Name: strings.Repeat("长", 60), Uuid: "0123456789abcdef"}, want: strings.Repeat("长", 50) + "-01234567"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exportFilename(tt.session); got != tt.want {
+				t.Errorf("exportFilename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{filename: "session.md", want: `attachment; filename="session.md"; filename*=UTF-8''session.md`},
+		{filename: "a b.md", want: `attachment; filename="a b.md"; filename*=UTF-8''a%20b.md`},
+		{filename: "会话.md", want: `attachment; filename="__.md"; filename*=UTF-8''%E4%BC%9A%E8%AF%9D.md`},
+		{filename: `a"b\c.md`, want: `attachment; filename="a_b_c.md"; filename*=UTF-8''a%22b%5Cc.md`},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := attachment(tt.filename); got != tt.want {
+				t.Errorf("attachment(%q) = %s, want %s", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSessionExport(t *testing.T) {
+	export := SessionExport{
+		Session: Session{Uuid: "0123456789abcdef", Name: "<b>会话</b>", Llm: OpenAILLM, Model: "gpt-4o"},
+		Contexts: []Context{
+			{UserContent: "<script>alert(1)</script>", AssistantContent: "回答", Llm: OpenAILLM, Model: "gpt-4o", TotalTokens: 30, PromptTokens: 10, CompletionTokens: 20, FinishReason: "stop"},
+		},
+	}
+	
+	for format, exporter := range sessionExporters {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := exporter.render(&buf, export)
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			
+			out := buf.String()
+			switch format {
+			case ExportJson:
+				var got SessionExport
+				if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got.Uuid != export.Uuid || len(got.Contexts) != 1 {
+					t.Errorf("json = %s, error = %v", out, err)
+				}
+			case ExportMarkdown:
+				for _, s := range []string{"# <b>会话</b>", "- 大模型: openai / gpt-4o", "## 1. 用户", "_openai / gpt-4o · tokens 30 (输入 10, 输出 20) · stop_"} {
+					if !strings.Contains(out, s) {
+						t.Errorf("markdown missing %q:\n%s", s, out)
+					}
+				}
+			case ExportHtml:
+				// 对话内容必须转义
+				if strings.Contains(out, "<script>") || !strings.Contains(out, "&lt;script&gt;") {
+					t.Errorf("html is not escaped:\n%s", out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package biz
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"time"
+)
+
+// 分支树的最大深度，避免 parent_uuid 出现环时无限查询
+
+const maxForkDepth = 100
+
+// 从 contextUuid 指定的对话分支，或者 turn 指定第几轮 (从 1 开始)
+
+type ForkSessionReq struct {
+	UserUuid    string `json:"userUuid,omitempty"`
+	Uuid        string `json:"uuid,omitempty" validate:"required"`
+	ContextUuid string `json:"contextUuid,omitempty" validate:"required_without=Turn"`
+	Turn        int    `json:"turn,omitempty" validate:"omitempty,min=1"`
+	Name        string `json:"name,omitempty"` // 为空时使用原会话名称
+}
+
+// ForkSession 复制会话到指定的一轮对话 (包括该轮) 为新会话，原会话不受影响
+// 新会话使用原会话的大模型和系统指令，历史摘要在新会话中重新生成
+
+func (sessionUseCase *SessionUseCase) ForkSession(c *gin.Context) {
+	req := ForkSessionReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	parent, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	contexts, err := sessionUseCase.sessionRepo.GetAllSessionContext(c.Request.Context(), parent.Uuid)
+	if err != nil {
+		sessionUseCase.logger.Error("查询会话上下文失败", zap.String("uuid", parent.Uuid), zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	turn := forkTurn(contexts, req.ContextUuid, req.Turn)
+	if turn == 0 {
+		c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+		return
+	}
+	
+	session := Session{
+		Uuid:              uuid.NewString(),
+		UserUuid:          parent.UserUuid,
+		Name:              req.Name,
+		Llm:               parent.Llm,
+		Model:             parent.Model,
+		SystemPrompt:      parent.SystemPrompt,
+		PersonaUuid:       parent.PersonaUuid,
+		ParentUuid:        parent.Uuid,
+		ParentContextUuid: contexts[turn-1].Uuid,
+		CreateTime:        time.Now().Unix(),
+	}
+	
+	if session.Name == "" {
+		session.Name = parent.Name
+	}
+	
+	err = sessionUseCase.sessionRepo.CreateSessionWithContext(c.Request.Context(), session, forkContexts(contexts[:turn], session.Uuid))
+	if err != nil {
+		sessionUseCase.logger.Error("创建分支会话失败", zap.String("uuid", parent.Uuid), zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "session": session, "turns": turn})
+}
+
+// 分支包含的对话轮数，contextUuid 优先，找不到对话或者超出范围时返回 0
+
+func forkTurn(contexts []Context, contextUuid string, turn int) int {
+	if contextUuid != "" {
+		turn = 0
+		for i, context := range contexts {
+			if context.Uuid == contextUuid {
+				turn = i + 1
+				break
+			}
+		}
+	}
+	
+	if turn <= 0 || turn > len(contexts) {
+		return 0
+	}
+	return turn
+}
+
+// 复制的对话使用新的 uuid，时间递增以保持原来的顺序
+// 复制的对话在原会话中已经计入用量，标记为 fork 后不再计入用量，也不出现在搜索结果中
+
+func forkContexts(contexts []Context, sessionUuid string) []Context {
+	forked := make([]Context, len(contexts))
+	var lastTime int64
+	for i, context := range contexts {
+		context.Uuid = uuid.NewString()
+		context.SessionUuid = sessionUuid
+		context.Source = ContextSourceFork
+		context.CreateTime = max(context.CreateTime, lastTime+1)
+		lastTime = context.CreateTime
+		forked[i] = context
+	}
+	return forked
+}
+
+type SessionTreeReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Uuid     string `json:"uuid,omitempty" form:"uuid" validate:"required"`
+}
+
+// SessionTree 返回会话所在分支树的所有会话，客户端根据 parentUuid 和 parentContextUuid 构建树
+// 来源会话被删除时，以最上层仍然存在的会话为根
+
+func (sessionUseCase *SessionUseCase) SessionTree(c *gin.Context) {
+	req := SessionTreeReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	root, ok := sessionUseCase.getSession(c, req.Uuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	ctx := c.Request.Context()
+	for depth := 0; root.ParentUuid != "" && depth < maxForkDepth; depth++ {
+		parent, err := sessionUseCase.sessionRepo.GetSession(ctx, root.ParentUuid, req.UserUuid)
+		if err != nil {
+			break
+		}
+		root = parent
+	}
+	
+	sessions := []Session{root}
+	visited := map[string]bool{root.Uuid: true}
+	parents := []string{root.Uuid}
+	for depth := 0; len(parents) > 0 && depth < maxForkDepth; depth++ {
+		children, err := sessionUseCase.sessionRepo.ListChildSession(ctx, req.UserUuid, parents)
+		if err != nil {
+			sessionUseCase.logger.Error("查询分支会话失败", zap.String("uuid", root.Uuid), zap.Error(err))
+			c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+			return
+		}
+		
+		parents = parents[:0]
+		for _, child := range children {
+			if visited[child.Uuid] {
+				continue
+			}
+			visited[child.Uuid] = true
+			sessions = append(sessions, child)
+			parents = append(parents, child.Uuid)
+		}
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "root": root.Uuid, "sessions": sessions})
+}
@@ -0,0 +1,62 @@
+package biz
+
+import "testing"
+
+func TestForkTurn(t *testing.T) {
+	contexts := []Context{{Uuid: "a"}, {Uuid: "b"}, {Uuid: "c"}}
+	
+	tests := []struct {
+		name        string
+		contextUuid string
+		turn        int
+		want        int
+	}{
+		{name: "指定对话", contextUuid: "b", want: 2},
+		{name: "contextUuid 优先于 turn", contextUuid: "a", turn: 3, want: 1},
+		{name: "对话不存在", contextUuid: "x", turn: 2, want: 0},
+		{name: "指定轮数", turn: 3, want: 3},
+		{name: "轮数超出范围", turn: 4, want: 0},
+		{name: "轮数为 0", want: 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := forkTurn(contexts, tt.contextUuid, tt.turn); got != tt.want {
+				t.Errorf("forkTurn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForkContexts(t *testing.T) {
+	contexts := []Context{
+		{Uuid: "a", SessionUuid: "parent", UserContent: "1", PromptTokens: 10, Llm: OpenAILLM, CreateTime: 100},
+		{Uuid: "b", SessionUuid: "parent", UserContent: "2", CreateTime: 100},
+		{Uuid: "c", SessionUuid: "parent", UserContent: "3", CreateTime: 99},
+		{Uuid: "d", SessionUuid: "parent", UserContent: "4", CreateTime: 200},
+	}
+	
+	forked := forkContexts(contexts[:forkTurn(contexts, "c", 0)], "child")
+	if len(forked) != 3 {
+		t.Fatalf("len(forked) = %d, want 3", len(forked))
+	}
+	
+	// 同一秒或时间倒退的对话时间递增，保持原来的顺序
+	wantTimes := []int64{100, 101, 102}
+	for i, context := range forked {
+		if context.Uuid == contexts[i].Uuid || context.SessionUuid != "child" || context.Source != ContextSourceFork {
+			t.Errorf("forked[%d] uuid = %q, sessionUuid = %q, source = %q", i, context.Uuid, context.SessionUuid, context.Source)
+		}
+		if context.UserContent != contexts[i].UserContent || context.CreateTime != wantTimes[i] {
+			t.Errorf("forked[%d] userContent = %q, createTime = %d, want %q, %d", i, context.UserContent, context.CreateTime, contexts[i].UserContent, wantTimes[i])
+		}
+	}
+	
+	// 保留原有的模型和 tokens 用于展示，原会话不受影响
+	if forked[0].PromptTokens != 10 || forked[0].Llm != OpenAILLM {
+		t.Errorf("forked[0] = %+v", forked[0])
+	}
+	if contexts[0].Uuid != "a" || contexts[0].SessionUuid != "parent" || contexts[0].Source != "" {
+		t.Errorf("contexts[0] = %+v", contexts[0])
+	}
+}
@@ -0,0 +1,498 @@
+package biz
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 导入格式，为空时自动识别
+
+const (
+	ImportChatGpt = "chatgpt" // ChatGPT 导出的 conversations.json，或包含 conversations.json 的 zip
+	ImportOpenAI  = "openai"  // OpenAI 格式的 messages 数组
+)
+
+// 导入文件的大小限制
+
+const importMaxSize = 64 << 20
+
+var errImportFormat = errors.New("unknown import format")
+
+type ImportOptions struct {
+	Format string
+	Llm    string // 为空时 ChatGPT 导出的对话使用 openai
+	Model  string // 消息中没有模型时使用
+}
+
+// ImportResult 导入的会话
+
+type ImportResult struct {
+	Uuid  string `json:"uuid"`
+	Name  string `json:"name"`
+	Turns int    `json:"turns"`
+}
+
+// 解析后的消息，role 为 system / user / assistant
+
+type importMessage struct {
+	role       string
+	content    string
+	model      string
+	createTime float64
+}
+
+type importConversation struct {
+	title      string
+	llm        string
+	model      string
+	createTime float64
+	messages   []importMessage
+}
+
+type ImportSessionReq struct {
+	UserUuid string `form:"userUuid"`
+	Format   string `form:"format" validate:"omitempty,oneof=chatgpt openai"`
+	Llm      string `form:"llm"`
+	Model    string `form:"model"`
+}
+
+// ImportSession 请求体为导入的文件，参数通过 query 传递
+
+func (sessionUseCase *SessionUseCase) ImportSession(c *gin.Context) {
+	req := ImportSessionReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
+		return
+	}
+	
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, importMaxSize))
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
+	results, err := sessionUseCase.Import(c.Request.Context(), req.UserUuid, body, ImportOptions{Format: req.Format, Llm: req.Llm, Model: req.Model})
+	if err != nil {
+		if len(results) == 0 {
+			c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+			return
+		}
+		
+		// 部分会话已经导入
+		sessionUseCase.logger.Error("导入会话失败", zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": err.Error(), "sessions": results})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "sessions": results})
+}
+
+// Import 解析并保存会话，每个会话在一个事务中保存，出错时返回已经导入的会话
+
+func (sessionUseCase *SessionUseCase) Import(ctx context.Context, userUuid string, data []byte, opts ImportOptions) ([]ImportResult, error) {
+	conversations, err := parseImport(data, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	
+	var results []ImportResult
+	for _, conversation := range conversations {
+		session, contexts := importSession(userUuid, conversation, opts)
+		if len(contexts) == 0 {
+			continue
+		}
+		
+		err = sessionUseCase.sessionRepo.CreateSessionWithContext(ctx, session, contexts)
+		if err != nil {
+			return results, fmt.Errorf("import %s: %w", session.Name, err)
+		}
+		
+		results = append(results, ImportResult{Uuid: session.Uuid, Name: session.Name, Turns: len(contexts)})
+	}
+	return results, nil
+}
+
+// 用户和助理的消息配对为一轮对话，连续的同角色消息合并
+
+func importSession(userUuid string, conversation importConversation, opts ImportOptions) (Session, []Context) {
+	now := time.Now().Unix()
+	session := Session{
+		Uuid:       uuid.NewString(),
+		UserUuid:   userUuid,
+		Name:       conversation.title,
+		CreateTime: importTime(conversation.createTime, now),
+	}
+	
+	var contexts []Context
+	lastTime := session.CreateTime - 1
+	
+	// 列表按 create_time 排序，同一秒内的对话需要递增以保持顺序
+	newTurn := func(message importMessage) *Context {
+		createTime := max(importTime(message.createTime, lastTime+1), lastTime+1)
+		lastTime = createTime
+		contexts = append(contexts, Context{CreateTime: createTime})
+		return &contexts[len(contexts)-1]
+	}
+	
+	var current *Context
+	for _, message := range conversation.messages {
+		switch message.role {
+		case "system":
+			if session.SystemPrompt == "" {
+				session.SystemPrompt = message.content
+			}
+		case "user":
+			if current == nil || current.AssistantContent != "" {
+				current = newTurn(message)
+			}
+			current.UserContent = joinContent(current.UserContent, message.content)
+		case "assistant":
+			if current == nil {
+				current = newTurn(message)
+			}
+			current.AssistantContent = joinContent(current.AssistantContent, message.content)
+			if message.model != "" {
+				current.Model = message.model
+			}
+		}
+	}
+	
+	for i := range contexts {
+		contexts[i].Uuid = uuid.NewString()
+		contexts[i].SessionUuid = session.Uuid
+		contexts[i].Source = ContextSourceImport
+		
+		contexts[i].Llm = opts.Llm
+		if contexts[i].Llm == "" {
+			contexts[i].Llm = conversation.llm
+		}
+		
+		if contexts[i].Model == "" {
+			contexts[i].Model = conversation.model
+		}
+		
+		if contexts[i].Model == "" {
+			contexts[i].Model = opts.Model
+		}
+	}
+	
+	if session.Name == "" && len(contexts) > 0 {
+		session.Name = truncate(strings.TrimSpace(contexts[0].UserContent), 30)
+	}
+	
+	if session.Name == "" {
+		session.Name = "导入的会话"
+	}
+	return session, contexts
+}
+
+func joinContent(content, s string) string {
+	if content == "" {
+		return s
+	}
+	return content + "\n\n" + s
+}
+
+// 导出文件中的时间为 unix 秒 (可能带小数)，没有时间时使用 fallback
+
+func importTime(t float64, fallback int64) int64 {
+	if t <= 0 {
+		return fallback
+	}
+	return int64(math.Floor(t))
+}
+
+// 解析导入文件，format 为空时根据内容识别
+
+func parseImport(data []byte, format string) ([]importConversation, error) {
+	// ChatGPT 导出的 zip
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		var err error
+		data, err = readConversationsJson(data)
+		if err != nil {
+			return nil, err
+		}
+		
+		if format == "" {
+			format = ImportChatGpt
+		}
+	}
+	
+	// 单个对象统一为数组处理
+	var items []json.RawMessage
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		items = append(items, data)
+	} else if json.Unmarshal(data, &items) != nil {
+		return nil, errImportFormat
+	}
+	
+	if len(items) == 0 {
+		return nil, nil
+	}
+	
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(items[0], &probe) != nil {
+		return nil, errImportFormat
+	}
+	
+	if format == "" {
+		switch {
+		case probe["mapping"] != nil:
+			format = ImportChatGpt
+		case probe["messages"] != nil || probe["role"] != nil:
+			format = ImportOpenAI
+		default:
+			return nil, errImportFormat
+		}
+	}
+	
+	switch format {
+	case ImportChatGpt:
+		return parseChatGpt(items)
+	case ImportOpenAI:
+		return parseOpenAI(items, probe["role"] != nil)
+	default:
+		return nil, errImportFormat
+	}
+}
+
+func readConversationsJson(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	
+	for _, file := range zr.File {
+		if file.Name != "conversations.json" && !strings.HasSuffix(file.Name, "/conversations.json") {
+			continue
+		}
+		
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(io.LimitReader(r, importMaxSize))
+	}
+	return nil, errors.New("conversations.json not found in zip")
+}
+
+// ChatGPT 导出格式，对话是以 mapping 保存的消息树，重新生成或编辑过的消息会产生分支
+
+type chatGptConversation struct {
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGptNode `json:"mapping"`
+}
+
+type chatGptNode struct {
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		Content struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+			Text        string            `json:"text"`
+		} `json:"content"`
+		CreateTime float64 `json:"create_time"`
+		Metadata   struct {
+			ModelSlug string `json:"model_slug"`
+			Hidden    bool   `json:"is_visually_hidden_from_conversation"`
+		} `json:"metadata"`
+	} `json:"message"`
+	Parent   string   `json:"parent"`
+	Children []string `json:"children"`
+}
+
+// 只导入当前显示的分支: 从 current_node 向上找到根节点
+
+func parseChatGpt(items []json.RawMessage) ([]importConversation, error) {
+	var conversations []importConversation
+	for _, item := range items {
+		var c chatGptConversation
+		err := json.Unmarshal(item, &c)
+		if err != nil {
+			return nil, err
+		}
+		
+		node := c.CurrentNode
+		if node == "" {
+			node = lastChatGptNode(c.Mapping)
+		}
+		
+		var messages []importMessage
+		visited := make(map[string]bool)
+		for node != "" && !visited[node] {
+			visited[node] = true
+			n, ok := c.Mapping[node]
+			if !ok {
+				break
+			}
+			
+			if m := n.Message; m != nil && !m.Metadata.Hidden {
+				content := m.Content.Text
+				for _, part := range m.Content.Parts {
+					var s string
+					if json.Unmarshal(part, &s) == nil && s != "" {
+						content = joinContent(content, s)
+					}
+				}
+				
+				if content != "" {
+					messages = append(messages, importMessage{role: m.Author.Role, content: content, model: m.Metadata.ModelSlug, createTime: m.CreateTime})
+				}
+			}
+			node = n.Parent
+		}
+		
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+		
+		conversations = append(conversations, importConversation{title: c.Title, llm: OpenAILLM, createTime: c.CreateTime, messages: messages})
+	}
+	
+	// 按创建时间导入
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].createTime < conversations[j].createTime
+	})
+	return conversations, nil
+}
+
+// 没有 current_node 时使用最后创建的叶子节点
+
+func lastChatGptNode(mapping map[string]chatGptNode) string {
+	var last string
+	var lastTime float64 = -1
+	for id, node := range mapping {
+		if len(node.Children) > 0 || node.Message == nil {
+			continue
+		}
+		
+		if node.Message.CreateTime > lastTime || (node.Message.CreateTime == lastTime && id > last) {
+			last, lastTime = id, node.Message.CreateTime
+		}
+	}
+	return last
+}
+
+// OpenAI 格式: messages 数组，或者 {"model": "", "messages": []} 对象及其数组
+
+type openAIMessage struct {
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	Model     string          `json:"model"`
+	CreatedAt float64         `json:"created_at"`
+}
+
+type openAIConversation struct {
+	Title     string          `json:"title"`
+	Name      string          `json:"name"`
+	Model     string          `json:"model"`
+	Created   float64         `json:"created"`
+	CreatedAt float64         `json:"created_at"`
+	Messages  []openAIMessage `json:"messages"`
+}
+
+func parseOpenAI(items []json.RawMessage, messagesOnly bool) ([]importConversation, error) {
+	var conversations []openAIConversation
+	if messagesOnly {
+		// 单个 messages 数组
+		var messages []openAIMessage
+		for _, item := range items {
+			var m openAIMessage
+			err := json.Unmarshal(item, &m)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, m)
+		}
+		conversations = append(conversations, openAIConversation{Messages: messages})
+	} else {
+		for _, item := range items {
+			var c openAIConversation
+			err := json.Unmarshal(item, &c)
+			if err != nil {
+				return nil, err
+			}
+			conversations = append(conversations, c)
+		}
+	}
+	
+	var result []importConversation
+	for _, c := range conversations {
+		conversation := importConversation{
+			title:      c.Title,
+			model:      c.Model,
+			createTime: max(c.Created, c.CreatedAt),
+		}
+		
+		if conversation.title == "" {
+			conversation.title = c.Name
+		}
+		
+		for _, m := range c.Messages {
+			conversation.messages = append(conversation.messages, importMessage{
+				role:       m.Role,
+				content:    openAIContent(m.Content),
+				model:      m.Model,
+				createTime: m.CreatedAt,
+			})
+		}
+		result = append(result, conversation)
+	}
+	return result, nil
+}
+
+// content 为字符串，或者 [{"type": "text", "text": ""}] 数组，图片等其他类型忽略
+
+func openAIContent(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	
+	var content string
+	for _, part := range parts {
+		if part.Type == "text" && part.Text != "" {
+			content = joinContent(content, part.Text)
+		}
+	}
+	return content
+}
@@ -0,0 +1,185 @@
+package biz
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 根节点 -> 隐藏的系统消息 -> 提问 -> 两个回答 (重新生成过一次)，current_node 为第二个回答
+
+const chatGptExport = `[{
+	"title": "分支",
+	"create_time": 1700000000.5,
+	"current_node": "a2",
+	"mapping": {
+		"root": {"message": null, "parent": "", "children": ["sys"]},
+		"sys": {"message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}, "parent": "root", "children": ["u1"]},
+		"u1": {"message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["你好"]}, "create_time": 1700000001}, "parent": "sys", "children": ["a1", "a2"]},
+		"a1": {"message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["旧的回答"]}, "create_time": 1700000002, "metadata": {"model_slug": "gpt-4"}}, "parent": "u1", "children": []},
+		"a2": {"message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["新的回答"]}, "create_time": 1700000003, "metadata": {"model_slug": "gpt-4o"}}, "parent": "u1", "children": []}
+	}
+}]`
+
+func TestParseChatGpt(t *testing.T) {
+	conversations, err := parseImport([]byte(chatGptExport), "")
+	if err != nil {
+		t.Fatalf("parseImport() error = %v", err)
+	}
+	if len(conversations) != 1 {
+		t.Fatalf("len(conversations) = %d, want 1", len(conversations))
+	}
+	
+	conversation := conversations[0]
+	if conversation.title != "分支" || conversation.llm != OpenAILLM {
+		t.Errorf("conversation = %+v", conversation)
+	}
+	
+	// 只导入 current_node 所在的分支，隐藏的消息和空消息忽略
+	want := []importMessage{
+		{role: "user", content: "你好", createTime: 1700000001},
+		{role: "assistant", content: "新的回答", model: "gpt-4o", createTime: 1700000003},
+	}
+	assertImportMessages(t, conversation.messages, want)
+}
+
+func TestParseChatGptWithoutCurrentNode(t *testing.T) {
+	data := bytes.Replace([]byte(chatGptExport), []byte(`"current_node": "a2",`), nil, 1)
+	
+	// 没有 current_node 时使用最后创建的叶子节点
+	conversations, err := parseImport(data, ImportChatGpt)
+	if err != nil {
+		t.Fatalf("parseImport() error = %v", err)
+	}
+	if len(conversations) != 1 || len(conversations[0].messages) != 2 || conversations[0].messages[1].content != "新的回答" {
+		t.Errorf("conversations = %+v", conversations)
+	}
+}
+
+func TestParseChatGptZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("export/conversations.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(chatGptExport))
+	_ = zw.Close()
+	
+	conversations, err := parseImport(buf.Bytes(), "")
+	if err != nil {
+		t.Fatalf("parseImport() error = %v", err)
+	}
+	if len(conversations) != 1 || conversations[0].title != "分支" {
+		t.Errorf("conversations = %+v", conversations)
+	}
+}
+
+func TestParseOpenAI(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantTitle string
+		wantModel string
+		want      []importMessage
+	}{
+		{
+			name: "messages 数组",
+			data: `[{"role": "system", "content": "你是一个助手"}, {"role": "user", "content": "你好"}, {"role": "assistant", "content": "你好！"}]`,
+			want: []importMessage{
+				{role: "system", content: "你是一个助手"},
+				{role: "user", content: "你好"},
+				{role: "assistant", content: "你好！"},
+			},
+		},
+		{
+			name:      "对象",
+			data:      `{"title": "标题", "model": "gpt-4o", "messages": [{"role": "user", "content": [{"type": "text", "text": "这是什么"}, {"type": "image_url", "image_url": {"url": "https://example.com/a.png"}}]}]}`,
+			wantTitle: "标题",
+			wantModel: "gpt-4o",
+			want: []importMessage{
+				{role: "user", content: "这是什么"},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversations, err := parseImport([]byte(tt.data), "")
+			if err != nil {
+				t.Fatalf("parseImport() error = %v", err)
+			}
+			if len(conversations) != 1 {
+				t.Fatalf("len(conversations) = %d, want 1", len(conversations))
+			}
+			if conversations[0].title != tt.wantTitle || conversations[0].model != tt.wantModel {
+				t.Errorf("title = %q, model = %q, want %q, %q", conversations[0].title, conversations[0].model, tt.wantTitle, tt.wantModel)
+			}
+			assertImportMessages(t, conversations[0].messages, tt.want)
+		})
+	}
+}
+
+func TestParseImportUnknownFormat(t *testing.T) {
+	for _, data := range []string{`not json`, `[{"foo": "bar"}]`, `"string"`} {
+		_, err := parseImport([]byte(data), "")
+		if err != errImportFormat {
+			t.Errorf("parseImport(%s) error = %v, want %v", data, err, errImportFormat)
+		}
+	}
+}
+
+func TestImportSession(t *testing.T) {
+	conversation := importConversation{
+		llm:        OpenAILLM,
+		model:      "gpt-4o",
+		createTime: 100,
+		messages: []importMessage{
+			{role: "system", content: "你是一个助手"},
+			{role: "user", content: "第一个问题", createTime: 100},
+			{role: "user", content: "补充"},
+			{role: "assistant", content: "回答", model: "gpt-4"},
+			{role: "user", content: "第二个问题", createTime: 100},
+		},
+	}
+	
+	session, contexts := importSession("user", conversation, ImportOptions{})
+	if session.SystemPrompt != "你是一个助手" || !strings.HasPrefix(session.Name, "第一个问题") || session.UserUuid != "user" {
+		t.Errorf("session = %+v", session)
+	}
+	if len(contexts) != 2 {
+		t.Fatalf("len(contexts) = %d, want 2", len(contexts))
+	}
+	
+	// 连续的提问合并为一轮，同一秒内的对话时间递增
+	first, second := contexts[0], contexts[1]
+	if first.UserContent != "第一个问题\n\n补充" || first.AssistantContent != "回答" || first.Model != "gpt-4" || first.CreateTime != 100 {
+		t.Errorf("contexts[0] = %+v", first)
+	}
+	if second.UserContent != "第二个问题" || second.AssistantContent != "" || second.Model != "gpt-4o" || second.CreateTime != 101 {
+		t.Errorf("contexts[1] = %+v", second)
+	}
+	if first.Llm != OpenAILLM || first.SessionUuid != session.Uuid {
+		t.Errorf("contexts[0] llm = %q, sessionUuid = %q", first.Llm, first.SessionUuid)
+	}
+	
+	// 导入的对话不计入用量
+	for i, c := range contexts {
+		if c.Source != ContextSourceImport {
+			t.Errorf("contexts[%d].Source = %q, want %q", i, c.Source, ContextSourceImport)
+		}
+	}
+}
+
+func assertImportMessages(t *testing.T, got, want []importMessage) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("messages = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("messages[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContextVersion 重新生成或编辑前的对话，同一个 Version 中包含被替换的对话及其之后的对话 (编辑时)
+
+type ContextVersion struct {
+	Uuid             string       `json:"uuid,omitempty"`
+	ContextUuid      string       `json:"contextUuid,omitempty"` // 被重新生成或编辑的对话
+	Version          int          `json:"version,omitempty"`
+	Position         int          `json:"position"`             // 在该版本中的顺序，0 为被替换的对话
+	SourceUuid       string       `json:"sourceUuid,omitempty"` // 对话原来的 uuid
+	SessionUuid      string       `json:"sessionUuid,omitempty"`
+	UserContent      string       `json:"userContent,omitempty"`
+	AssistantContent string       `json:"assistantContent,omitempty"`
+	PromptTokens     int          `json:"promptTokens,omitempty"`
+	CompletionTokens int          `json:"completionTokens,omitempty"`
+	TotalTokens      int          `json:"totalTokens,omitempty"`
+	FinishReason     string       `json:"finishReason,omitempty"`
+	Llm              string       `json:"llm,omitempty"`
+	Model            string       `json:"model,omitempty"`
+	Options          chat.Options `json:"options,omitempty" gorm:"serializer:json"`
+	Source           string       `json:"source,omitempty"`
+	CreateTime       int64        `json:"createTime,omitempty"`
+	ArchiveTime      int64        `json:"archiveTime,omitempty"`
+}
+
+func (contextVersion ContextVersion) TableName() string {
+	return "session_context_version"
+}
+
+// 被替换的对话和之后的对话保存为同一个版本
+
+func contextVersions(replaced Context, following []Context) []ContextVersion {
+	now := time.Now().Unix()
+	version := max(replaced.Version, 1)
+	
+	var versions []ContextVersion
+	for i, c := range append([]Context{replaced}, following...) {
+		versions = append(versions, ContextVersion{
+			Uuid:             uuid.NewString(),
+			ContextUuid:      replaced.Uuid,
+			Version:          version,
+			Position:         i,
+			SourceUuid:       c.Uuid,
+			SessionUuid:      c.SessionUuid,
+			UserContent:      c.UserContent,
+			AssistantContent: c.AssistantContent,
+			PromptTokens:     c.PromptTokens,
+			CompletionTokens: c.CompletionTokens,
+			TotalTokens:      c.TotalTokens,
+			FinishReason:     c.FinishReason,
+			Llm:              c.Llm,
+			Model:            c.Model,
+			Options:          c.Options,
+			Source:           c.Source,
+			CreateTime:       c.CreateTime,
+			ArchiveTime:      now,
+		})
+	}
+	return versions
+}
+
+// 本轮对话的历史上下文，按时间倒序
+// replaceUuid 不为空时只使用被替换的对话之前的上下文，同时返回被替换的对话和之后的对话
+
+func (sessionUseCase *SessionUseCase) historyContexts(ctx context.Context, sessionUuid, replaceUuid string) ([]Context, Context, []Context, error) {
+	if replaceUuid == "" {
+		contexts, err := sessionUseCase.sessionRepo.GetRecentSessionContext(ctx, sessionUuid, sessionUseCase.contextMaxTurns())
+		return contexts, Context{}, nil, err
+	}
+	
+	all, err := sessionUseCase.sessionRepo.GetAllSessionContext(ctx, sessionUuid)
+	if err != nil {
+		return nil, Context{}, nil, err
+	}
+	
+	for i, c := range all {
+		if c.Uuid != replaceUuid {
+			continue
+		}
+		
+		history := append([]Context{}, all[max(i-sessionUseCase.contextMaxTurns(), 0):i]...)
+		for l, r := 0, len(history)-1; l < r; l, r = l+1, r-1 {
+			history[l], history[r] = history[r], history[l]
+		}
+		return history, c, all[i+1:], nil
+	}
+	return nil, Context{}, nil, gorm.ErrRecordNotFound
+}
+
+// 选择模型、检查额度后生成本轮回答，stream 为 true 或 Accept: text/event-stream 时使用 SSE 流式返回
+
+func (sessionUseCase *SessionUseCase) respondGenerate(c *gin.Context, gReq generateReq, llm, model string, stream bool) {
+	gReq.llm, gReq.model = sessionUseCase.chooseModel(gReq.session, llm, model)
+	
+	err := sessionUseCase.validateOptions(gReq.llm, gReq.options)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
+	if !sessionUseCase.quotaUseCase.allow(c, gReq.session.UserUuid, gReq.llm) {
+		return
+	}
+	
+	//
+	if stream || strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		sessionUseCase.streamSessionContext(c, gReq)
+		return
+	}
+	
+	sessionContext, err := sessionUseCase.generate(c.Request.Context(), gReq, nil)
+	if err != nil {
+		if errors.Is(err, errUnSupportLLM) {
+			c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": "UnSupport LLM"})
+			return
+		}
+		c.JSON(200, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "content": sessionContext.AssistantContent, "context": sessionContext})
+}
+
+type RegenerateSessionContextReq struct {
+	UserUuid    string `json:"userUuid,omitempty"`
+	SessionUuid string `json:"sessionUuid,omitempty" validate:"required"`
+	Stream      bool   `json:"stream,omitempty"`
+	Llm         string `json:"llm,omitempty"`   // 为空时使用会话的大模型
+	Model       string `json:"model,omitempty"` // 为空时使用会话的模型
+	chat.Options
+}
+
+// RegenerateSessionContext 重新生成最后一轮回答，原来的回答保存为历史版本
+
+func (sessionUseCase *SessionUseCase) RegenerateSessionContext(c *gin.Context) {
+	req := RegenerateSessionContextReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.SessionUuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	last, err := sessionUseCase.sessionRepo.GetLastSessionContext(c.Request.Context(), session.Uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+			return
+		}
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	gReq := generateReq{session: session, content: last.UserContent, options: req.Options, replaceUuid: last.Uuid}
+	sessionUseCase.respondGenerate(c, gReq, req.Llm, req.Model, req.Stream)
+}
+
+type EditSessionContextReq struct {
+	UserUuid    string `json:"userUuid,omitempty"`
+	SessionUuid string `json:"sessionUuid,omitempty" validate:"required"`
+	ContextUuid string `json:"contextUuid,omitempty" validate:"required"`
+	Content     string `json:"content,omitempty" validate:"required"` // 修改后的提问
+	Stream      bool   `json:"stream,omitempty"`
+	Llm         string `json:"llm,omitempty"`
+	Model       string `json:"model,omitempty"`
+	chat.Options
+}
+
+// EditSessionContext 修改一轮对话的提问并重新生成，该轮及之后的对话保存为历史版本后从会话中移除
+
+func (sessionUseCase *SessionUseCase) EditSessionContext(c *gin.Context) {
+	req := EditSessionContextReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	if !sessionUseCase.supportLLM(req.Llm) {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "UnSupport LLM"})
+		return
+	}
+	
+	session, ok := sessionUseCase.getSession(c, req.SessionUuid, req.UserUuid)
+	if !ok {
+		return
+	}
+	
+	_, err = sessionUseCase.sessionRepo.GetSessionContextByUuid(c.Request.Context(), session.Uuid, req.ContextUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+			return
+		}
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	gReq := generateReq{session: session, content: req.Content, options: req.Options, replaceUuid: req.ContextUuid}
+	sessionUseCase.respondGenerate(c, gReq, req.Llm, req.Model, req.Stream)
+}
+
+type ListContextVersionReq struct {
+	UserUuid    string `json:"userUuid,omitempty" form:"userUuid"`
+	SessionUuid string `json:"sessionUuid,omitempty" form:"sessionUuid" validate:"required"`
+	ContextUuid string `json:"contextUuid,omitempty" form:"contextUuid" validate:"required"`
+}
+
+type contextVersionGroup struct {
+	Version  int              `json:"version"`
+	Contexts []ContextVersion `json:"contexts"`
+}
+
+// ListContextVersion 返回一轮对话的当前版本和历史版本，历史版本按版本号分组
+
+func (sessionUseCase *SessionUseCase) ListContextVersion(c *gin.Context) {
+	req := ListContextVersionReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	req.UserUuid, err = resolveUser(c, req.UserUuid)
+	if err != nil {
+		return
+	}
+	
+	e, err := sessionUseCase.sessionRepo.ExistsSession(c.Request.Context(), req.SessionUuid, req.UserUuid)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	if !e {
+		c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+		return
+	}
+	
+	// 之前的对话被编辑后，该轮对话已经不在会话中，只返回历史版本
+	current, err := sessionUseCase.sessionRepo.GetSessionContextByUuid(c.Request.Context(), req.SessionUuid, req.ContextUuid)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	versions, err := sessionUseCase.sessionRepo.ListContextVersion(c.Request.Context(), req.SessionUuid, req.ContextUuid)
+	if err != nil {
+		sessionUseCase.logger.Error("查询对话历史版本失败", zap.String("uuid", req.ContextUuid), zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	groups := make([]contextVersionGroup, 0)
+	index := make(map[int]int)
+	for _, version := range versions {
+		i, ok := index[version.Version]
+		if !ok {
+			i = len(groups)
+			index[version.Version] = i
+			groups = append(groups, contextVersionGroup{Version: version.Version})
+		}
+		groups[i].Contexts = append(groups[i].Contexts, version)
+	}
+	
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Version < groups[j].Version
+	})
+	
+	if current.Uuid == "" && len(groups) == 0 {
+		c.JSON(404, gin.H{"errCode": errCode.NotFoundCode, "errMsg": errCode.NotFoundMsg})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg, "current": current, "versions": groups})
+}
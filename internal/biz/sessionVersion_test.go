@@ -0,0 +1,209 @@
+package biz
+
+import (
+	"context"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/chat"
+	"go.uber.org/zap"
+	"testing"
+)
+
+// 只实现生成一轮对话需要的方法，其他方法调用时 panic
+
+type fakeSessionRepo struct {
+	SessionRepo
+	contexts []Context // 按时间正序
+	replaced Context
+	versions []ContextVersion
+	inserted []Context
+	summary  Session // 保存的摘要
+}
+
+func (fake *fakeSessionRepo) GetAllSessionContext(ctx context.Context, sessionUuid string) ([]Context, error) {
+	return fake.contexts, nil
+}
+
+func (fake *fakeSessionRepo) GetRecentSessionContext(ctx context.Context, sessionUuid string, limit int) ([]Context, error) {
+	var contexts []Context
+	for i := len(fake.contexts) - 1; i >= 0 && len(contexts) < limit; i-- {
+		contexts = append(contexts, fake.contexts[i])
+	}
+	return contexts, nil
+}
+
+func (fake *fakeSessionRepo) InsertSessionContext(ctx context.Context, c Context) error {
+	fake.inserted = append(fake.inserted, c)
+	return nil
+}
+
+func (fake *fakeSessionRepo) ReplaceSessionContext(ctx context.Context, c Context, versions []ContextVersion) error {
+	fake.replaced = c
+	fake.versions = versions
+	return nil
+}
+
+func (fake *fakeSessionRepo) UpdateSessionSummary(ctx context.Context, uuid, summary string, summaryTime int64, summaryUuid string) error {
+	fake.summary = Session{Uuid: uuid, Summary: summary, SummaryTime: summaryTime, SummaryUuid: summaryUuid}
+	return nil
+}
+
+type fakeChatProvider struct {
+	resp     chat.Response
+	err      error
+	requests []chat.Request
+}
+
+func (fake *fakeChatProvider) Limits() chat.Limits {
+	return chat.Limits{}
+}
+
+func (fake *fakeChatProvider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	fake.requests = append(fake.requests, req)
+	return fake.resp, fake.err
+}
+
+func (fake *fakeChatProvider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	return fake.Chat(ctx, req)
+}
+
+func newTestSessionUseCase(sessionRepo SessionRepo, quotaUseCase *QuotaUseCase, pedant *conf.Pedant, providers map[string]chat.Provider) *SessionUseCase {
+	registry := chat.NewRegistry()
+	for name, provider := range providers {
+		registry.Register(name, provider)
+	}
+	
+	if quotaUseCase == nil {
+		quotaUseCase = NewQuotaUseCase(nil, pedant, zap.NewNop())
+	}
+	return NewSessionUseCase(sessionRepo, nil, nil, registry, quotaUseCase, pedant, &conf.Llm{}, zap.NewNop())
+}
+
+func TestContextVersions(t *testing.T) {
+	replaced := Context{Uuid: "b", SessionUuid: "s", UserContent: "2", AssistantContent: "旧的回答", PromptTokens: 10, Llm: OpenAILLM, Version: 2, Source: ContextSourceImport, CreateTime: 100}
+	following := []Context{{Uuid: "c", SessionUuid: "s", UserContent: "3", CreateTime: 101}}
+	
+	versions := contextVersions(replaced, following)
+	if len(versions) != 2 {
+		t.Fatalf("len(versions) = %d, want 2", len(versions))
+	}
+	
+	// 被替换的对话和之后的对话保存为同一个版本，都关联到被替换的对话
+	for i, version := range versions {
+		if version.ContextUuid != "b" || version.Version != 2 || version.Position != i || version.ArchiveTime == 0 || version.Uuid == "" {
+			t.Errorf("versions[%d] = %+v", i, version)
+		}
+	}
+	
+	first, second := versions[0], versions[1]
+	if first.SourceUuid != "b" || first.AssistantContent != "旧的回答" || first.PromptTokens != 10 || first.Llm != OpenAILLM || first.Source != ContextSourceImport || first.CreateTime != 100 {
+		t.Errorf("versions[0] = %+v", first)
+	}
+	if second.SourceUuid != "c" || second.UserContent != "3" || second.CreateTime != 101 {
+		t.Errorf("versions[1] = %+v", second)
+	}
+	
+	// 之前没有版本号的对话 (添加 version 列之前保存的) 作为第 1 版
+	replaced.Version = 0
+	if versions = contextVersions(replaced, nil); len(versions) != 1 || versions[0].Version != 1 {
+		t.Errorf("versions = %+v, want version 1", versions)
+	}
+}
+
+func TestGenerateReplace(t *testing.T) {
+	history := []Context{
+		{Uuid: "a", SessionUuid: "s", UserContent: "1", AssistantContent: "回答1", Version: 1, CreateTime: 100},
+		{Uuid: "b", SessionUuid: "s", UserContent: "2", AssistantContent: "回答2", Version: 2, CreateTime: 101},
+		{Uuid: "c", SessionUuid: "s", UserContent: "3", AssistantContent: "回答3", Version: 1, Source: ContextSourceFork, CreateTime: 102},
+	}
+	
+	tests := []struct {
+		name         string
+		replaceUuid  string
+		content      string
+		wantHistory  []string // 发送给大模型的历史提问
+		wantVersion  int      // 替换后的版本
+		wantArchived []string // 保存为历史版本的对话
+	}{
+		{
+			name:         "重新生成最后一轮",
+			replaceUuid:  "c",
+			content:      "3",
+			wantHistory:  []string{"1", "2"},
+			wantVersion:  2,
+			wantArchived: []string{"c"},
+		},
+		{
+			name:         "编辑之前的一轮，之后的对话一起保存",
+			replaceUuid:  "b",
+			content:      "修改后的2",
+			wantHistory:  []string{"1"},
+			wantVersion:  3,
+			wantArchived: []string{"b", "c"},
+		},
+		{
+			name:        "新增一轮对话",
+			content:     "4",
+			wantHistory: []string{"1", "2", "3"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessionRepo{contexts: history}
+			provider := &fakeChatProvider{resp: chat.Response{Content: "新的回答", Usage: chat.Usage{TotalTokens: 5}}}
+			sessionUseCase := newTestSessionUseCase(repo, nil, &conf.Pedant{Llm: OpenAILLM}, map[string]chat.Provider{OpenAILLM: provider})
+			
+			req := generateReq{session: Session{Uuid: "s"}, content: tt.content, llm: OpenAILLM, replaceUuid: tt.replaceUuid}
+			c, err := sessionUseCase.generate(context.Background(), req, nil)
+			if err != nil {
+				t.Fatalf("generate() error = %v", err)
+			}
+			
+			var got []string
+			for _, message := range provider.requests[0].Messages {
+				if message.Role == chat.RoleUser {
+					got = append(got, message.Content)
+				}
+			}
+			if want := append(tt.wantHistory, tt.content); !equalStrings(got, want) {
+				t.Errorf("user messages = %v, want %v", got, want)
+			}
+			
+			if tt.replaceUuid == "" {
+				if len(repo.inserted) != 1 || repo.inserted[0].Uuid == "" || repo.inserted[0].Version != 1 || repo.versions != nil {
+					t.Errorf("inserted = %+v, versions = %+v", repo.inserted, repo.versions)
+				}
+				return
+			}
+			
+			// uuid 不变，版本递增，重新生成的对话不再是复制的对话
+			if c.Uuid != tt.replaceUuid || repo.replaced.Uuid != tt.replaceUuid || repo.replaced.Version != tt.wantVersion ||
+				repo.replaced.AssistantContent != "新的回答" || repo.replaced.Source != "" || len(repo.inserted) != 0 {
+				t.Errorf("replaced = %+v, inserted = %+v", repo.replaced, repo.inserted)
+			}
+			
+			var archived []string
+			for _, version := range repo.versions {
+				archived = append(archived, version.SourceUuid)
+				if version.Version != tt.wantVersion-1 {
+					t.Errorf("version %s = %d, want %d", version.SourceUuid, version.Version, tt.wantVersion-1)
+				}
+			}
+			if !equalStrings(archived, tt.wantArchived) {
+				t.Errorf("archived = %v, want %v", archived, tt.wantArchived)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,334 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// WebSocket 客户端帧类型
+
+const (
+	WsOpen       = "open"       // 打开会话
+	WsMessage    = "message"    // 发送消息
+	WsCancel     = "cancel"     // 取消当前生成
+	WsStop       = "stop"       // 同 cancel
+	WsRegenerate = "regenerate" // 重新生成最后一轮回答
+	WsEdit       = "edit"       // 修改 contextUuid 对话的提问并重新生成
+)
+
+// WebSocket 服务端帧类型
+
+const (
+	WsOpened    = "opened"
+	WsDelta     = "delta"
+	WsDone      = "done"
+	WsCancelled = "cancelled"
+	WsError     = "error"
+)
+
+// 浏览器无法为 WebSocket 设置请求头，token 通过子协议传递: Sec-WebSocket-Protocol: pedant, bearer.<token>
+// 服务端只选择 pedant 子协议，不会在响应中返回 token
+
+const (
+	WsProtocol            = "pedant"
+	wsTokenProtocolPrefix = "bearer."
+)
+
+func newUpgrader(webSocket *conf.WebSocket) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{WsProtocol},
+		CheckOrigin:     checkOrigin(webSocket.GetAllowedOrigins()),
+	}
+}
+
+// 没有 Origin 的客户端 (非浏览器) 和同源的页面总是允许，其他 Origin 需要在 allowedOrigins 中
+
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type ChatWsReq struct {
+	Type        string `json:"type"`
+	UserUuid    string `json:"userUuid,omitempty"`
+	SessionUuid string `json:"sessionUuid,omitempty"`
+	ContextUuid string `json:"contextUuid,omitempty"` // edit 时被修改的对话
+	Content     string `json:"content,omitempty"`
+	Llm         string `json:"llm,omitempty"`   // message / regenerate / edit 时覆盖会话的大模型
+	Model       string `json:"model,omitempty"` // message / regenerate / edit 时覆盖会话的模型
+	chat.Options
+}
+
+// 单个 WebSocket 连接的状态，同一时间只允许一个生成任务
+
+type chatWsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex // websocket 不支持并发写
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	session Session
+}
+
+func (ws *chatWsConn) write(v any) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return ws.conn.WriteJSON(v)
+}
+
+func (ws *chatWsConn) writeError(code int, msg string) {
+	_ = ws.write(gin.H{"type": WsError, "errCode": code, "errMsg": msg})
+}
+
+// 取消正在进行的生成任务
+
+func (ws *chatWsConn) stop() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.cancel != nil {
+		ws.cancel()
+	}
+}
+
+// ChatWebSocket
+// 客户端先发送 open 帧打开会话，之后发送 message / regenerate 帧开始生成，生成过程中可以发送 cancel / stop 帧中止
+// 中止或连接断开时会取消上游大模型的请求，已经生成的部分内容依然保存到 session_context 中
+
+func (sessionUseCase *SessionUseCase) ChatWebSocket(c *gin.Context) {
+	conn, err := sessionUseCase.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经返回了 HTTP 错误
+		sessionUseCase.logger.Error("升级 WebSocket 连接失败", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	
+	ws := &chatWsConn{conn: conn}
+	defer func() {
+		ws.stop()
+		ws.wg.Wait()
+	}()
+	
+	for {
+		req := ChatWsReq{}
+		err = conn.ReadJSON(&req)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				sessionUseCase.logger.Info("读取 WebSocket 消息失败", zap.Error(err))
+			}
+			return
+		}
+		
+		switch req.Type {
+		case WsOpen:
+			sessionUseCase.openWsSession(c.Request.Context(), ws, req)
+		case WsMessage:
+			if req.Content == "" {
+				ws.writeError(errCode.ParameterFormatErrCode, "content is required")
+				continue
+			}
+			sessionUseCase.startWsGenerate(c.Request.Context(), ws, req, generateReq{content: req.Content})
+		case WsRegenerate:
+			sessionUseCase.regenerateWs(c.Request.Context(), ws, req)
+		case WsEdit:
+			sessionUseCase.editWs(c.Request.Context(), ws, req)
+		case WsCancel, WsStop:
+			ws.stop()
+		default:
+			ws.writeError(errCode.ParameterFormatErrCode, "unknown frame type")
+		}
+	}
+}
+
+func (sessionUseCase *SessionUseCase) openWsSession(ctx context.Context, ws *chatWsConn, req ChatWsReq) {
+	if req.SessionUuid == "" {
+		ws.writeError(errCode.ParameterFormatErrCode, "sessionUuid is required")
+		return
+	}
+	
+	userUuid, err := userFromContext(ctx, req.UserUuid)
+	if err != nil {
+		switch {
+		case errors.Is(err, errUserRequired):
+			ws.writeError(errCode.ParameterFormatErrCode, err.Error())
+		case errors.Is(err, errUserMismatch):
+			ws.writeError(errCode.UserPermissionDenyCode, errCode.UserPermissionDenyMsg)
+		default:
+			ws.writeError(errCode.UserUnAuthorizeCode, errCode.UserUnAuthorizeMsg)
+		}
+		return
+	}
+	
+	session, err := sessionUseCase.sessionRepo.GetSession(ctx, req.SessionUuid, userUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ws.writeError(errCode.NotFoundCode, errCode.NotFoundMsg)
+			return
+		}
+		ws.writeError(errCode.BizOpErrorCode, errCode.BizOpErrorMsg)
+		return
+	}
+	
+	// 生成过程中切换会话会导致上下文错乱
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.cancel != nil {
+		ws.writeError(errCode.BizOpErrorCode, "generation in progress")
+		return
+	}
+	
+	ws.session = session
+	_ = ws.write(gin.H{"type": WsOpened, "sessionUuid": session.Uuid, "llm": session.Llm, "model": session.Model})
+}
+
+func (sessionUseCase *SessionUseCase) regenerateWs(ctx context.Context, ws *chatWsConn, wsReq ChatWsReq) {
+	ws.mu.Lock()
+	sessionUuid := ws.session.Uuid
+	ws.mu.Unlock()
+	
+	if sessionUuid == "" {
+		ws.writeError(errCode.ParameterFormatErrCode, "session is not open")
+		return
+	}
+	
+	last, err := sessionUseCase.sessionRepo.GetLastSessionContext(ctx, sessionUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ws.writeError(errCode.NotFoundCode, errCode.NotFoundMsg)
+			return
+		}
+		ws.writeError(errCode.BizOpErrorCode, errCode.BizOpErrorMsg)
+		return
+	}
+	
+	sessionUseCase.startWsGenerate(ctx, ws, wsReq, generateReq{content: last.UserContent, replaceUuid: last.Uuid})
+}
+
+func (sessionUseCase *SessionUseCase) editWs(ctx context.Context, ws *chatWsConn, wsReq ChatWsReq) {
+	if wsReq.ContextUuid == "" || wsReq.Content == "" {
+		ws.writeError(errCode.ParameterFormatErrCode, "contextUuid and content are required")
+		return
+	}
+	
+	ws.mu.Lock()
+	sessionUuid := ws.session.Uuid
+	ws.mu.Unlock()
+	
+	if sessionUuid == "" {
+		ws.writeError(errCode.ParameterFormatErrCode, "session is not open")
+		return
+	}
+	
+	_, err := sessionUseCase.sessionRepo.GetSessionContextByUuid(ctx, sessionUuid, wsReq.ContextUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ws.writeError(errCode.NotFoundCode, errCode.NotFoundMsg)
+			return
+		}
+		ws.writeError(errCode.BizOpErrorCode, errCode.BizOpErrorMsg)
+		return
+	}
+	
+	sessionUseCase.startWsGenerate(ctx, ws, wsReq, generateReq{content: wsReq.Content, replaceUuid: wsReq.ContextUuid})
+}
+
+// 在后台生成，读循环继续处理 cancel 等控制帧
+
+func (sessionUseCase *SessionUseCase) startWsGenerate(ctx context.Context, ws *chatWsConn, wsReq ChatWsReq, req generateReq) {
+	if !sessionUseCase.supportLLM(wsReq.Llm) {
+		ws.writeError(errCode.ParameterFormatErrCode, "UnSupport LLM")
+		return
+	}
+	
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	
+	if ws.session.Uuid == "" {
+		ws.writeError(errCode.ParameterFormatErrCode, "session is not open")
+		return
+	}
+	
+	if ws.cancel != nil {
+		ws.writeError(errCode.BizOpErrorCode, "generation in progress")
+		return
+	}
+	
+	req.session = ws.session
+	req.llm, req.model = sessionUseCase.chooseModel(ws.session, wsReq.Llm, wsReq.Model)
+	req.options = wsReq.Options
+	
+	err := sessionUseCase.validateOptions(req.llm, req.options)
+	if err != nil {
+		ws.writeError(errCode.ParameterFormatErrCode, err.Error())
+		return
+	}
+	
+	status, err := sessionUseCase.quotaUseCase.Check(ctx, ws.session.UserUuid, req.llm)
+	if err != nil {
+		_ = ws.write(gin.H{"type": WsError, "errCode": errCode.LimitRequestCode, "errMsg": err.Error(), "quota": status})
+		return
+	}
+	
+	genCtx, cancel := context.WithCancel(ctx)
+	ws.cancel = cancel
+	ws.wg.Add(1)
+	
+	go func() {
+		defer ws.wg.Done()
+		defer func() {
+			ws.mu.Lock()
+			ws.cancel = nil
+			ws.mu.Unlock()
+			cancel()
+		}()
+		
+		sessionContext, err := sessionUseCase.generate(genCtx, req, func(delta string) error {
+			return ws.write(gin.H{"type": WsDelta, "content": delta})
+		})
+		
+		if err != nil {
+			if genCtx.Err() != nil {
+				_ = ws.write(gin.H{"type": WsCancelled, "context": sessionContext})
+				return
+			}
+			
+			ws.writeError(errCode.BizOpErrorCode, errCode.BizOpErrorMsg)
+			return
+		}
+		
+		_ = ws.write(gin.H{"type": WsDone, "context": sessionContext})
+	}()
+}
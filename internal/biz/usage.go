@@ -0,0 +1,282 @@
+package biz
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/qx66/pedant/internal/biz/common"
+	"github.com/qx66/pedant/internal/conf"
+	"github.com/startopsz/rule/pkg/response/errCode"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 用量来源
+
+const (
+	UsageKindChat       = "chat"
+	UsageKindMultiModal = "multimodal"
+	UsageKindImage      = "image"
+)
+
+// 文生图只支持千帆 Stable-Diffusion-XL，image 表中没有记录大模型
+
+const imageModel = "sd_xl"
+
+// Usage 按 用户 + 来源 + 大模型 + 模型 + 日期 汇总的用量
+
+type Usage struct {
+	UserUuid         string  `json:"userUuid,omitempty"`
+	Kind             string  `json:"kind,omitempty"`
+	Llm              string  `json:"llm,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Day              string  `json:"day,omitempty"` // 2006-01-02，数据库时区
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+type UsageFilter struct {
+	UserUuid  string // 为空时查询所有用户
+	StartTime int64  // 包含
+	EndTime   int64  // 不包含
+}
+
+type UsageRepo interface {
+	ListUsage(ctx context.Context, filter UsageFilter) ([]Usage, error)
+}
+
+type UsageUseCase struct {
+	usageRepo UsageRepo
+	pedant    *conf.Pedant
+	logger    *zap.Logger
+}
+
+func NewUsageUseCase(usageRepo UsageRepo, pedant *conf.Pedant, logger *zap.Logger) *UsageUseCase {
+	return &UsageUseCase{
+		usageRepo: usageRepo,
+		pedant:    pedant,
+		logger:    logger,
+	}
+}
+
+// 汇总维度
+
+var usageDimensions = map[string]func(usage *Usage) *string{
+	"user":  func(usage *Usage) *string { return &usage.UserUuid },
+	"kind":  func(usage *Usage) *string { return &usage.Kind },
+	"llm":   func(usage *Usage) *string { return &usage.Llm },
+	"model": func(usage *Usage) *string { return &usage.Model },
+	"day":   func(usage *Usage) *string { return &usage.Day },
+}
+
+type UsageReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid"`
+	Start    string `json:"start,omitempty" form:"start"`     // 2006-01-02，默认本月第一天
+	End      string `json:"end,omitempty" form:"end"`         // 2006-01-02 (包含)，默认今天
+	GroupBy  string `json:"groupBy,omitempty" form:"groupBy"` // user / kind / llm / model / day，逗号分隔，默认 user,llm,model,day
+	Format   string `json:"format,omitempty" form:"format"`   // json (默认) / csv
+}
+
+// Report 用量和费用报表，需要 admin 权限
+
+func (usageUseCase *UsageUseCase) Report(c *gin.Context) {
+	req := UsageReq{}
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	filter, err := usageFilter(req)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": err.Error()})
+		return
+	}
+	
+	groupBy := strings.Split(req.GroupBy, ",")
+	if req.GroupBy == "" {
+		groupBy = []string{"user", "llm", "model", "day"}
+	}
+	
+	for _, dimension := range groupBy {
+		if _, ok := usageDimensions[dimension]; !ok {
+			c.JSON(400, gin.H{"errCode": errCode.ParameterFormatErrCode, "errMsg": "unknown groupBy: " + dimension})
+			return
+		}
+	}
+	
+	usages, err := usageUseCase.usageRepo.ListUsage(c.Request.Context(), filter)
+	if err != nil {
+		usageUseCase.logger.Error("查询用量失败", zap.Error(err))
+		c.JSON(500, gin.H{"errCode": errCode.BizOpErrorCode, "errMsg": errCode.BizOpErrorMsg})
+		return
+	}
+	
+	// 费用按模型计算，之后再按维度汇总
+	for i := range usages {
+		if usages[i].Kind == UsageKindImage {
+			usages[i].Llm = usageUseCase.pedant.GetImageLlm()
+			usages[i].Model = imageModel
+		}
+		usages[i].Cost = usageUseCase.cost(usages[i])
+	}
+	
+	rows := rollupUsage(usages, groupBy)
+	total := rollupUsage(usages, nil)
+	
+	if req.Format == "csv" {
+		filename := fmt.Sprintf("usage-%s-%s.csv",
+			time.Unix(filter.StartTime, 0).Format(time.DateOnly), time.Unix(filter.EndTime, 0).AddDate(0, 0, -1).Format(time.DateOnly))
+		writeUsageCsv(c, rows, groupBy, filename)
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": errCode.NormalCode, "errMsg": errCode.NormalMsg,
+		"currency": usageUseCase.pedant.GetBilling().GetCurrency(), "usages": rows, "total": total[0]})
+}
+
+func usageFilter(req UsageReq) (UsageFilter, error) {
+	now := time.Now()
+	start := startOfDay(now).AddDate(0, 0, 1-now.Day())
+	end := startOfDay(now)
+	
+	var err error
+	if req.Start != "" {
+		start, err = time.ParseInLocation(time.DateOnly, req.Start, time.Local)
+		if err != nil {
+			return UsageFilter{}, fmt.Errorf("invalid start: %s", req.Start)
+		}
+	}
+	
+	if req.End != "" {
+		end, err = time.ParseInLocation(time.DateOnly, req.End, time.Local)
+		if err != nil {
+			return UsageFilter{}, fmt.Errorf("invalid end: %s", req.End)
+		}
+	}
+	
+	if end.Before(start) {
+		return UsageFilter{}, fmt.Errorf("end is before start")
+	}
+	
+	return UsageFilter{
+		UserUuid:  req.UserUuid,
+		StartTime: start.Unix(),
+		EndTime:   end.AddDate(0, 0, 1).Unix(),
+	}, nil
+}
+
+func (usageUseCase *UsageUseCase) cost(usage Usage) float64 {
+	price := usageUseCase.price(usage.Llm, usage.Model)
+	if price == nil {
+		return 0
+	}
+	
+	// 只记录了总 tokens 数时按输入价格计算
+	promptTokens := usage.PromptTokens
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		promptTokens = usage.TotalTokens
+	}
+	
+	return price.GetInput()*float64(promptTokens)/1e6 +
+		price.GetOutput()*float64(usage.CompletionTokens)/1e6 +
+		price.GetRequest()*float64(usage.Requests)
+}
+
+func (usageUseCase *UsageUseCase) price(llm, model string) *conf.Price {
+	var fallback *conf.Price
+	for _, price := range usageUseCase.pedant.GetBilling().GetPrices() {
+		if price.GetLlm() != llm {
+			continue
+		}
+		
+		if price.GetModel() == model {
+			return price
+		}
+		
+		if price.GetModel() == "" {
+			fallback = price
+		}
+	}
+	return fallback
+}
+
+// 按 groupBy 汇总，groupBy 之外的字段置空
+
+func rollupUsage(usages []Usage, groupBy []string) []Usage {
+	index := make(map[string]int)
+	var rows []Usage
+	
+	for _, usage := range usages {
+		row := Usage{}
+		var key []string
+		for _, dimension := range groupBy {
+			value := *usageDimensions[dimension](&usage)
+			*usageDimensions[dimension](&row) = value
+			key = append(key, value)
+		}
+		
+		k := strings.Join(key, "\x00")
+		i, ok := index[k]
+		if !ok {
+			i = len(rows)
+			index[k] = i
+			rows = append(rows, row)
+		}
+		
+		rows[i].Requests += usage.Requests
+		rows[i].PromptTokens += usage.PromptTokens
+		rows[i].CompletionTokens += usage.CompletionTokens
+		rows[i].TotalTokens += usage.TotalTokens
+		rows[i].Cost += usage.Cost
+	}
+	
+	// 没有用量时合计为 0
+	if len(rows) == 0 && len(groupBy) == 0 {
+		rows = append(rows, Usage{})
+	}
+	
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, dimension := range groupBy {
+			a, b := *usageDimensions[dimension](&rows[i]), *usageDimensions[dimension](&rows[j])
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	return rows
+}
+
+func writeUsageCsv(c *gin.Context, rows []Usage, groupBy []string, filename string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(200)
+	
+	w := csv.NewWriter(c.Writer)
+	header := append(append([]string{}, groupBy...), "requests", "promptTokens", "completionTokens", "totalTokens", "cost")
+	_ = w.Write(header)
+	
+	for _, row := range rows {
+		var record []string
+		for _, dimension := range groupBy {
+			record = append(record, *usageDimensions[dimension](&row))
+		}
+		
+		record = append(record,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatFloat(row.Cost, 'f', 6, 64),
+		)
+		_ = w.Write(record)
+	}
+	w.Flush()
+}
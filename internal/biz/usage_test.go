@@ -0,0 +1,175 @@
+package biz
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/qx66/pedant/internal/conf"
+	"math"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRollupUsage(t *testing.T) {
+	usages := []Usage{
+		{UserUuid: "u2", Kind: UsageKindChat, Llm: OpenAILLM, Model: "gpt-4o", Day: "2024-05-01", Requests: 1, PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, Cost: 0.5},
+		{UserUuid: "u1", Kind: UsageKindChat, Llm: OpenAILLM, Model: "gpt-4o", Day: "2024-05-02", Requests: 2, PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3, Cost: 0.25},
+		{UserUuid: "u1", Kind: UsageKindMultiModal, Llm: GoogleLLM, Model: "", Day: "2024-05-01", Requests: 3, TotalTokens: 100, Cost: 1},
+		{UserUuid: "u1", Kind: UsageKindChat, Llm: OpenAILLM, Model: "gpt-4o-mini", Day: "2024-05-01", Requests: 4, TotalTokens: 7},
+	}
+	
+	tests := []struct {
+		name    string
+		usages  []Usage
+		groupBy []string
+		want    []Usage
+	}{
+		{
+			name:    "按大模型和模型汇总，其他维度置空",
+			usages:  usages,
+			groupBy: []string{"llm", "model"},
+			want: []Usage{
+				{Llm: GoogleLLM, Requests: 3, TotalTokens: 100, Cost: 1},
+				{Llm: OpenAILLM, Model: "gpt-4o", Requests: 3, PromptTokens: 11, CompletionTokens: 22, TotalTokens: 33, Cost: 0.75},
+				{Llm: OpenAILLM, Model: "gpt-4o-mini", Requests: 4, TotalTokens: 7},
+			},
+		},
+		{
+			name:    "按维度顺序排序",
+			usages:  usages,
+			groupBy: []string{"user", "day"},
+			want: []Usage{
+				{UserUuid: "u1", Day: "2024-05-01", Requests: 7, TotalTokens: 107, Cost: 1},
+				{UserUuid: "u1", Day: "2024-05-02", Requests: 2, PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3, Cost: 0.25},
+				{UserUuid: "u2", Day: "2024-05-01", Requests: 1, PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, Cost: 0.5},
+			},
+		},
+		{
+			name:   "没有维度时返回合计",
+			usages: usages,
+			want:   []Usage{{Requests: 10, PromptTokens: 11, CompletionTokens: 22, TotalTokens: 140, Cost: 1.75}},
+		},
+		{
+			name: "没有用量时合计为 0",
+			want: []Usage{{}},
+		},
+		{
+			name:    "没有用量时按维度汇总为空",
+			groupBy: []string{"llm"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rollupUsage(tt.usages, tt.groupBy)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rollupUsage() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rows[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	usageUseCase := NewUsageUseCase(nil, &conf.Pedant{Billing: &conf.Billing{Prices: []*conf.Price{
+		{Llm: OpenAILLM, Input: 1, Output: 2},
+		{Llm: OpenAILLM, Model: "gpt-4o", Input: 5, Output: 15},
+		{Llm: BaiduCloudLLM, Model: imageModel, Input: 1, Request: 0.02},
+	}}}, nil)
+	
+	tests := []struct {
+		name  string
+		usage Usage
+		want  float64
+	}{
+		{
+			name:  "按模型匹配价格",
+			usage: Usage{Llm: OpenAILLM, Model: "gpt-4o", Requests: 1, PromptTokens: 1e6, CompletionTokens: 2e6},
+			want:  35,
+		},
+		{
+			name:  "没有模型的价格时使用大模型的价格",
+			usage: Usage{Llm: OpenAILLM, Model: "gpt-4o-mini", Requests: 1, PromptTokens: 1e6, CompletionTokens: 1e6},
+			want:  3,
+		},
+		{
+			name:  "只记录了总 tokens 数时按输入价格计算，加上每次请求的价格",
+			usage: Usage{Llm: BaiduCloudLLM, Model: imageModel, Requests: 10, TotalTokens: 1e6},
+			want:  1.2,
+		},
+		{
+			name:  "没有配置价格的大模型费用为 0",
+			usage: Usage{Llm: GoogleLLM, Model: "gemini-pro", Requests: 1, PromptTokens: 1e6},
+			want:  0,
+		},
+		{
+			name:  "只有其他模型的价格时费用为 0",
+			usage: Usage{Llm: BaiduCloudLLM, Model: "ernie-4.0", Requests: 1, PromptTokens: 1e6},
+			want:  0,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usageUseCase.cost(tt.usage); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	
+	// 没有配置 billing
+	usageUseCase = NewUsageUseCase(nil, &conf.Pedant{}, nil)
+	if price := usageUseCase.price(OpenAILLM, "gpt-4o"); price != nil {
+		t.Errorf("price() = %+v, want nil", price)
+	}
+}
+
+func TestWriteUsageCsv(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	
+	tests := []struct {
+		name    string
+		rows    []Usage
+		groupBy []string
+		want    string
+	}{
+		{
+			name:    "按维度输出列",
+			rows:    []Usage{{UserUuid: "u1", Llm: OpenAILLM, Requests: 2, PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, Cost: 0.1234567}},
+			groupBy: []string{"user", "llm"},
+			want: "user,llm,requests,promptTokens,completionTokens,totalTokens,cost\n" +
+				"u1,openai,2,10,20,30,0.123457\n",
+		},
+		{
+			name:    "包含逗号和引号的值",
+			rows:    []Usage{{Model: `a,"b"`, Requests: 1}},
+			groupBy: []string{"model"},
+			want: "model,requests,promptTokens,completionTokens,totalTokens,cost\n" +
+				`"a,""b""",1,0,0,0,0.000000` + "\n",
+		},
+		{
+			name: "没有维度和数据时只输出表头",
+			want: "requests,promptTokens,completionTokens,totalTokens,cost\n",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			writeUsageCsv(c, tt.rows, tt.groupBy, "usage-2024-05-01-2024-05-31.csv")
+			
+			if recorder.Code != 200 || recorder.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+				t.Errorf("code = %d, content-type = %q", recorder.Code, recorder.Header().Get("Content-Type"))
+			}
+			if got := recorder.Header().Get("Content-Disposition"); got != `attachment; filename="usage-2024-05-01-2024-05-31.csv"` {
+				t.Errorf("content-disposition = %q", got)
+			}
+			if got := recorder.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,8 +15,12 @@ import (
 var ProviderSet = wire.NewSet(NewData,
 	NewLocalCacheDataSource,
 	NewSessionDataSource,
+	NewPersonaDataSource,
 	NewMultiModalDataSource,
-	NewImageDataSource)
+	NewImageDataSource,
+	NewQuotaDataSource,
+	NewUsageDataSource,
+	NewSearchDataSource)
 
 // Data .
 type Data struct {
@@ -20,12 +20,10 @@ func (imageDataSource *imageDataSource) CreateImage(ctx context.Context, image b
 	return tx.Error
 }
 
-func (imageDataSource *imageDataSource) ListImage(ctx context.Context, userUuid string) ([]biz.Image, error) {
+func (imageDataSource *imageDataSource) ListImage(ctx context.Context, userUuid string, page biz.Page) ([]biz.Image, error) {
 	var images []biz.Image
-	tx := imageDataSource.data.db.WithContext(ctx).
-		Where("user_uuid = ?", userUuid).
-		Order("create_time desc").
-		Limit(20).
+	tx := paginate(imageDataSource.data.db.WithContext(ctx).
+		Where("user_uuid = ?", userUuid), page).
 		Find(&images)
 	return images, tx.Error
 }
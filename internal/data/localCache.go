@@ -1,6 +1,10 @@
 package data
 
-import "github.com/qx66/pedant/internal/biz"
+import (
+	"errors"
+	"github.com/allegro/bigcache/v3"
+	"github.com/qx66/pedant/internal/biz"
+)
 
 type localCacheDataSource struct {
 	data *Data
@@ -19,3 +23,11 @@ func (localCacheDataSource *localCacheDataSource) SetLocalCache(key string, valu
 func (localCacheDataSource *localCacheDataSource) GetLocalCache(key string) ([]byte, error) {
 	return localCacheDataSource.data.localCache.Get(key)
 }
+
+func (localCacheDataSource *localCacheDataSource) DeleteLocalCache(key string) error {
+	err := localCacheDataSource.data.localCache.Delete(key)
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil
+	}
+	return err
+}
@@ -20,12 +20,10 @@ func (multiModalDataSource *multiModalDataSource) CreateMultiModal(ctx context.C
 	return tx.Error
 }
 
-func (multiModalDataSource *multiModalDataSource) ListMultiModal(ctx context.Context, userUuid string) ([]biz.MultiModal, error) {
+func (multiModalDataSource *multiModalDataSource) ListMultiModal(ctx context.Context, userUuid string, page biz.Page) ([]biz.MultiModal, error) {
 	var multiModals []biz.MultiModal
-	tx := multiModalDataSource.data.db.WithContext(ctx).
-		Where("user_uuid = ?", userUuid).
-		Order("create_time desc").
-		Limit(20).
+	tx := paginate(multiModalDataSource.data.db.WithContext(ctx).
+		Where("user_uuid = ?", userUuid), page).
 		Find(&multiModals)
 	
 	return multiModals, tx.Error
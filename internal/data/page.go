@@ -0,0 +1,30 @@
+package data
+
+import (
+	"github.com/qx66/pedant/internal/biz"
+	"gorm.io/gorm"
+)
+
+// 按 (create_time, uuid) 分页，多查询一条用于判断是否还有下一页
+
+func paginate(db *gorm.DB, page biz.Page) *gorm.DB {
+	if page.StartTime > 0 {
+		db = db.Where("create_time >= ?", page.StartTime)
+	}
+	
+	if page.EndTime > 0 {
+		db = db.Where("create_time < ?", page.EndTime)
+	}
+	
+	op, order := ">", "create_time, uuid"
+	if page.Desc {
+		op, order = "<", "create_time desc, uuid desc"
+	}
+	
+	if page.After != nil {
+		db = db.Where("(create_time "+op+" ? or (create_time = ? and uuid "+op+" ?))",
+			page.After.CreateTime, page.After.CreateTime, page.After.Uuid)
+	}
+	
+	return db.Order(order).Limit(page.Size + 1)
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"context"
+	"github.com/qx66/pedant/internal/biz"
+)
+
+type personaDataSource struct {
+	data *Data
+}
+
+func NewPersonaDataSource(data *Data) biz.PersonaRepo {
+	return &personaDataSource{
+		data: data,
+	}
+}
+
+func (personaDataSource *personaDataSource) CreatePersona(ctx context.Context, persona biz.Persona) error {
+	tx := personaDataSource.data.db.WithContext(ctx).Create(&persona)
+	return tx.Error
+}
+
+func (personaDataSource *personaDataSource) ListPersona(ctx context.Context, userUuid string) ([]biz.Persona, error) {
+	var personas []biz.Persona
+	tx := personaDataSource.data.db.WithContext(ctx).
+		Where("user_uuid = ?", userUuid).
+		Order("create_time").
+		Find(&personas)
+	
+	return personas, tx.Error
+}
+
+func (personaDataSource *personaDataSource) GetPersona(ctx context.Context, uuid, userUuid string) (biz.Persona, error) {
+	var persona biz.Persona
+	tx := personaDataSource.data.db.WithContext(ctx).
+		Where("uuid = ? and user_uuid = ?", uuid, userUuid).
+		First(&persona)
+	return persona, tx.Error
+}
+
+func (personaDataSource *personaDataSource) UpdatePersona(ctx context.Context, persona biz.Persona) error {
+	tx := personaDataSource.data.db.WithContext(ctx).
+		Model(&biz.Persona{}).
+		Where("uuid = ? and user_uuid = ?", persona.Uuid, persona.UserUuid).
+		Select("name", "system_prompt", "update_time").
+		Updates(&persona)
+	return tx.Error
+}
+
+func (personaDataSource *personaDataSource) DeletePersona(ctx context.Context, uuid, userUuid string) error {
+	tx := personaDataSource.data.db.WithContext(ctx).
+		Where("uuid = ? and user_uuid = ?", uuid, userUuid).
+		Delete(&biz.Persona{})
+	return tx.Error
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"github.com/qx66/pedant/internal/biz"
+	"github.com/qx66/pedant/internal/conf"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// 过期计数的清理间隔
+
+const quotaCleanInterval = 10 * time.Minute
+
+func NewQuotaDataSource(data *Data, pedant *conf.Pedant) biz.QuotaRepo {
+	if pedant.GetQuota().GetStore() == biz.QuotaStoreMysql {
+		return &quotaDataSource{
+			data: data,
+		}
+	}
+	
+	return &quotaMemoryDataSource{
+		counters: make(map[string]quotaCounter),
+	}
+}
+
+// 进程内计数，重启后清零
+
+type quotaCounter struct {
+	value    int64
+	expireAt time.Time
+}
+
+type quotaMemoryDataSource struct {
+	mu        sync.Mutex
+	counters  map[string]quotaCounter
+	cleanedAt time.Time
+}
+
+func (quotaMemoryDataSource *quotaMemoryDataSource) IncrQuotaCounter(ctx context.Context, key string, delta int64, expireAt time.Time) (int64, error) {
+	quotaMemoryDataSource.mu.Lock()
+	defer quotaMemoryDataSource.mu.Unlock()
+	
+	now := time.Now()
+	if now.Sub(quotaMemoryDataSource.cleanedAt) > quotaCleanInterval {
+		for k, counter := range quotaMemoryDataSource.counters {
+			if now.After(counter.expireAt) {
+				delete(quotaMemoryDataSource.counters, k)
+			}
+		}
+		quotaMemoryDataSource.cleanedAt = now
+	}
+	
+	counter := quotaMemoryDataSource.counters[key]
+	if now.After(counter.expireAt) {
+		counter.value = 0
+	}
+	
+	counter.value += delta
+	counter.expireAt = expireAt
+	quotaMemoryDataSource.counters[key] = counter
+	return counter.value, nil
+}
+
+func (quotaMemoryDataSource *quotaMemoryDataSource) GetQuotaCounter(ctx context.Context, key string) (int64, error) {
+	quotaMemoryDataSource.mu.Lock()
+	defer quotaMemoryDataSource.mu.Unlock()
+	
+	counter, ok := quotaMemoryDataSource.counters[key]
+	if !ok || time.Now().After(counter.expireAt) {
+		return 0, nil
+	}
+	return counter.value, nil
+}
+
+// 多实例共享计数，保存在 quota_counter 表中
+
+type quotaDataSource struct {
+	data      *Data
+	mu        sync.Mutex
+	cleanedAt time.Time
+}
+
+func (quotaDataSource *quotaDataSource) IncrQuotaCounter(ctx context.Context, key string, delta int64, expireAt time.Time) (int64, error) {
+	quotaDataSource.clean(ctx)
+	
+	var value int64
+	err := quotaDataSource.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		e := tx.Exec("insert into quota_counter (counter_key, value, expire_time) values (?, ?, ?) "+
+			"on duplicate key update value = value + values(value), expire_time = values(expire_time)",
+			key, delta, expireAt.Unix()).Error
+		if e != nil {
+			return e
+		}
+		
+		return tx.Raw("select value from quota_counter where counter_key = ?", key).Scan(&value).Error
+	})
+	return value, err
+}
+
+func (quotaDataSource *quotaDataSource) GetQuotaCounter(ctx context.Context, key string) (int64, error) {
+	var value int64
+	tx := quotaDataSource.data.db.WithContext(ctx).
+		Raw("select value from quota_counter where counter_key = ? and expire_time > ?", key, time.Now().Unix()).
+		Scan(&value)
+	return value, tx.Error
+}
+
+// 删除过期的计数，多个实例同时清理也没有影响
+
+func (quotaDataSource *quotaDataSource) clean(ctx context.Context) {
+	quotaDataSource.mu.Lock()
+	now := time.Now()
+	if now.Sub(quotaDataSource.cleanedAt) < quotaCleanInterval {
+		quotaDataSource.mu.Unlock()
+		return
+	}
+	quotaDataSource.cleanedAt = now
+	quotaDataSource.mu.Unlock()
+	
+	tx := quotaDataSource.data.db.WithContext(ctx).Exec("delete from quota_counter where expire_time < ?", now.Unix())
+	if tx.Error != nil {
+		quotaDataSource.data.logger.Error("清理过期的限流计数失败", zap.Error(tx.Error))
+	}
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"github.com/qx66/pedant/internal/biz"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+)
+
+// 全文索引需要包含的列，与 match 的列一致
+
+const searchColumns = "user_content,assistant_content"
+
+type searchDataSource struct {
+	data     *Data
+	mu       sync.Mutex
+	fullText map[string]bool // 表是否有全文索引，启动后第一次搜索时检查
+}
+
+func NewSearchDataSource(data *Data) biz.SearchRepo {
+	return &searchDataSource{
+		data:     data,
+		fullText: make(map[string]bool),
+	}
+}
+
+func (searchDataSource *searchDataSource) SearchSessionContext(ctx context.Context, filter biz.SearchFilter) ([]biz.SearchHit, error) {
+	var hits []biz.SearchHit
+	db := searchDataSource.data.db.WithContext(ctx).
+		Table("session_context sc").
+		Joins("join session s on s.uuid = sc.session_uuid").
+		Where("s.user_uuid = ? and s.delete_time = 0 and sc.delete_time = 0 and sc.source <> ?", filter.UserUuid, biz.ContextSourceFork)
+	
+	tx := searchDataSource.match(ctx, db, "session_context", "sc", filter,
+		"sc.uuid, sc.session_uuid, s.name as session_name, sc.user_content, sc.assistant_content, sc.create_time").
+		Scan(&hits)
+	
+	for i := range hits {
+		hits[i].Kind = biz.UsageKindChat
+	}
+	return hits, tx.Error
+}
+
+func (searchDataSource *searchDataSource) SearchMultiModal(ctx context.Context, filter biz.SearchFilter) ([]biz.SearchHit, error) {
+	var hits []biz.SearchHit
+	db := searchDataSource.data.db.WithContext(ctx).
+		Table("multi_modal mm").
+		Where("mm.user_uuid = ?", filter.UserUuid)
+	
+	tx := searchDataSource.match(ctx, db, "multi_modal", "mm", filter,
+		"mm.uuid, mm.user_content, mm.assistant_content, mm.create_time").
+		Scan(&hits)
+	
+	for i := range hits {
+		hits[i].Kind = biz.UsageKindMultiModal
+	}
+	return hits, tx.Error
+}
+
+// 有全文索引时使用 match ... against 并按相关度排序，否则使用 like 匹配并按时间倒序
+
+func (searchDataSource *searchDataSource) match(ctx context.Context, db *gorm.DB, table, alias string, filter biz.SearchFilter, columns string) *gorm.DB {
+	if filter.StartTime > 0 {
+		db = db.Where(alias+".create_time >= ?", filter.StartTime)
+	}
+	
+	if filter.EndTime > 0 {
+		db = db.Where(alias+".create_time < ?", filter.EndTime)
+	}
+	
+	if searchDataSource.hasFullText(ctx, table) {
+		against := "match(" + alias + ".user_content, " + alias + ".assistant_content) against (? in boolean mode)"
+		query := booleanQuery(filter.Terms)
+		return db.Select(columns+", "+against+" as score", query).
+			Where(against, query).
+			Order("score desc, " + alias + ".create_time desc").
+			Limit(filter.Limit)
+	}
+	
+	for _, term := range filter.Terms {
+		like := "%" + escapeLike(term) + "%"
+		db = db.Where("("+alias+".user_content like ? or "+alias+".assistant_content like ?)", like, like)
+	}
+	return db.Select(columns).
+		Order(alias + ".create_time desc").
+		Limit(filter.Limit)
+}
+
+// 检查失败时使用 like 匹配，下次搜索时重新检查
+
+func (searchDataSource *searchDataSource) hasFullText(ctx context.Context, table string) bool {
+	searchDataSource.mu.Lock()
+	defer searchDataSource.mu.Unlock()
+	
+	if fullText, ok := searchDataSource.fullText[table]; ok {
+		return fullText
+	}
+	
+	var indexes []string
+	tx := searchDataSource.data.db.WithContext(ctx).
+		Raw("select index_name from information_schema.statistics "+
+			"where table_schema = database() and table_name = ? and index_type = 'FULLTEXT' "+
+			"group by index_name having group_concat(column_name order by seq_in_index) = ?", table, searchColumns).
+		Scan(&indexes)
+	if tx.Error != nil {
+		searchDataSource.data.logger.Error("检查全文索引失败", zap.String("table", table), zap.Error(tx.Error))
+		return false
+	}
+	
+	searchDataSource.fullText[table] = len(indexes) > 0
+	return len(indexes) > 0
+}
+
+// 所有关键词都需要匹配，关键词按短语匹配，避免用户输入被当作布尔运算符
+
+func booleanQuery(terms []string) string {
+	var query []string
+	for _, term := range terms {
+		term = strings.ReplaceAll(term, `"`, " ")
+		query = append(query, `+"`+term+`"`)
+	}
+	return strings.Join(query, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
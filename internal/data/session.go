@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/qx66/pedant/internal/biz"
 	"gorm.io/gorm"
+	"time"
 )
 
 type sessionDataSource struct {
@@ -21,27 +22,117 @@ func (sessionDataSource *sessionDataSource) CreateSession(ctx context.Context, s
 	return tx.Error
 }
 
-func (sessionDataSource *sessionDataSource) ListSession(ctx context.Context, userUuid string) ([]biz.Session, error) {
+// 导入或分支的会话和上下文在同一个事务中保存
+
+func (sessionDataSource *sessionDataSource) CreateSessionWithContext(ctx context.Context, session biz.Session, contexts []biz.Context) error {
+	return sessionDataSource.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&session).Error
+		if err != nil {
+			return err
+		}
+		
+		return tx.CreateInBatches(&contexts, 100).Error
+	})
+}
+
+func (sessionDataSource *sessionDataSource) ListSession(ctx context.Context, filter biz.SessionFilter, page biz.Page) ([]biz.Session, error) {
+	var sessions []biz.Session
+	db := sessionDataSource.data.db.WithContext(ctx).
+		Where("user_uuid = ? and archived = ? and delete_time = 0", filter.UserUuid, filter.Archived)
+	if filter.Pinned {
+		db = db.Where("pinned = ?", true)
+	}
+	
+	tx := paginate(db, page).Find(&sessions)
+	
+	return sessions, tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) ListChildSession(ctx context.Context, userUuid string, parentUuids []string) ([]biz.Session, error) {
 	var sessions []biz.Session
 	tx := sessionDataSource.data.db.WithContext(ctx).
-		Where("user_uuid = ?", userUuid).
-		Order("create_time").
-		Limit(20).
+		Where("user_uuid = ? and parent_uuid in ? and delete_time = 0", userUuid, parentUuids).
+		Order("create_time, uuid").
 		Find(&sessions)
-	
 	return sessions, tx.Error
 }
 
-func (sessionDataSource *sessionDataSource) DeleteSession(ctx context.Context, uuid, userUuid string) error {
+// 导出时使用，包括归档的会话
+
+func (sessionDataSource *sessionDataSource) ListAllSession(ctx context.Context, userUuid string) ([]biz.Session, error) {
+	var sessions []biz.Session
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("user_uuid = ? and delete_time = 0", userUuid).
+		Order("create_time, uuid").
+		Find(&sessions)
+	return sessions, tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) UpdateSession(ctx context.Context, session biz.Session) error {
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Model(&biz.Session{}).
+		Where("uuid = ? and user_uuid = ? and delete_time = 0", session.Uuid, session.UserUuid).
+		Select("name", "llm", "model", "system_prompt", "persona_uuid").
+		Updates(&session)
+	return tx.Error
+}
+
+// 并发请求时只保存更新的摘要
+
+func (sessionDataSource *sessionDataSource) UpdateSessionSummary(ctx context.Context, uuid, summary string, summaryTime int64, summaryUuid string) error {
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Model(&biz.Session{}).
+		Where("uuid = ? and (summary_time is null or summary_time < ? or (summary_time = ? and coalesce(summary_uuid, '') < ?))", uuid, summaryTime, summaryTime, summaryUuid).
+		Updates(map[string]interface{}{"summary": summary, "summary_time": summaryTime, "summary_uuid": summaryUuid})
+	return tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) GetSession(ctx context.Context, uuid, userUuid string) (biz.Session, error) {
+	var session biz.Session
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("uuid = ? and user_uuid = ? and delete_time = 0", uuid, userUuid).
+		First(&session)
+	return session, tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) ArchiveSession(ctx context.Context, uuid, userUuid string, archived bool) error {
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Model(&biz.Session{}).
+		Where("uuid = ? and user_uuid = ? and delete_time = 0", uuid, userUuid).
+		Update("archived", archived)
+	return tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) PinSession(ctx context.Context, uuid, userUuid string, pinned bool) error {
 	tx := sessionDataSource.data.db.WithContext(ctx).
-		Where("uuid = ? and user_uuid = ?", uuid, userUuid).
-		Delete(&biz.Session{})
+		Model(&biz.Session{}).
+		Where("uuid = ? and user_uuid = ? and delete_time = 0", uuid, userUuid).
+		Update("pinned", pinned)
 	return tx.Error
 }
 
+// 会话和上下文在同一个事务中软删除，会话不属于该用户时不删除上下文
+// 上下文保留在表中，用量报表依然统计已删除会话的用量
+
+func (sessionDataSource *sessionDataSource) DeleteSession(ctx context.Context, uuid, userUuid string) error {
+	now := time.Now().Unix()
+	return sessionDataSource.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&biz.Session{}).
+			Where("uuid = ? and user_uuid = ? and delete_time = 0", uuid, userUuid).
+			Update("delete_time", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		
+		return tx.Table("session_context").
+			Where("session_uuid = ? and delete_time = 0", uuid).
+			Update("delete_time", now).Error
+	})
+}
+
 func (sessionDataSource *sessionDataSource) ExistsSession(ctx context.Context, uuid, userUuid string) (bool, error) {
 	tx := sessionDataSource.data.db.WithContext(ctx).
-		Where("uuid = ? and user_uuid = ?", uuid, userUuid).
+		Where("uuid = ? and user_uuid = ? and delete_time = 0", uuid, userUuid).
 		First(&biz.Session{})
 	if tx.Error != nil {
 		if tx.Error == gorm.ErrRecordNotFound {
@@ -52,11 +143,30 @@ func (sessionDataSource *sessionDataSource) ExistsSession(ctx context.Context, u
 	return true, nil
 }
 
-func (sessionDataSource *sessionDataSource) GetSessionContext(ctx context.Context, sessionUuid string) ([]biz.Context, error) {
+func (sessionDataSource *sessionDataSource) GetSessionContext(ctx context.Context, sessionUuid string, page biz.Page) ([]biz.Context, error) {
+	var contexts []biz.Context
+	tx := paginate(sessionDataSource.data.db.WithContext(ctx).
+		Where("session_uuid = ?", sessionUuid), page).
+		Find(&contexts)
+	return contexts, tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) GetAllSessionContext(ctx context.Context, sessionUuid string) ([]biz.Context, error) {
+	var contexts []biz.Context
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("session_uuid = ?", sessionUuid).
+		Order("create_time, uuid").
+		Find(&contexts)
+	return contexts, tx.Error
+}
+
+// 最新的 limit 轮对话，按时间倒序
+
+func (sessionDataSource *sessionDataSource) GetRecentSessionContext(ctx context.Context, sessionUuid string, limit int) ([]biz.Context, error) {
 	var contexts []biz.Context
 	tx := sessionDataSource.data.db.WithContext(ctx).
 		Where("session_uuid = ?", sessionUuid).
-		Order("create_time").Limit(20).
+		Order("create_time desc, uuid desc").Limit(limit).
 		Find(&contexts)
 	return contexts, tx.Error
 }
@@ -65,3 +175,60 @@ func (sessionDataSource *sessionDataSource) InsertSessionContext(ctx context.Con
 	tx := sessionDataSource.data.db.WithContext(ctx).Create(&c)
 	return tx.Error
 }
+
+func (sessionDataSource *sessionDataSource) GetLastSessionContext(ctx context.Context, sessionUuid string) (biz.Context, error) {
+	var c biz.Context
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("session_uuid = ?", sessionUuid).
+		Order("create_time desc, uuid desc").
+		First(&c)
+	return c, tx.Error
+}
+
+// 被替换的对话和之后的对话保存到 session_context_version，之后的对话从会话中移除
+
+func (sessionDataSource *sessionDataSource) ReplaceSessionContext(ctx context.Context, c biz.Context, versions []biz.ContextVersion) error {
+	var following []string
+	for _, version := range versions {
+		if version.SourceUuid != c.Uuid {
+			following = append(following, version.SourceUuid)
+		}
+	}
+	
+	return sessionDataSource.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.CreateInBatches(&versions, 100).Error
+		if err != nil {
+			return err
+		}
+		
+		if len(following) > 0 {
+			err = tx.Where("session_uuid = ? and uuid in ?", c.SessionUuid, following).
+				Delete(&biz.Context{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		
+		return tx.Model(&biz.Context{}).
+			Where("uuid = ? and session_uuid = ?", c.Uuid, c.SessionUuid).
+			Select("user_content", "assistant_content", "prompt_tokens", "completion_tokens", "total_tokens", "finish_reason", "llm", "model", "options", "version", "source", "create_time").
+			Updates(&c).Error
+	})
+}
+
+func (sessionDataSource *sessionDataSource) GetSessionContextByUuid(ctx context.Context, sessionUuid, uuid string) (biz.Context, error) {
+	var c biz.Context
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("uuid = ? and session_uuid = ?", uuid, sessionUuid).
+		First(&c)
+	return c, tx.Error
+}
+
+func (sessionDataSource *sessionDataSource) ListContextVersion(ctx context.Context, sessionUuid, contextUuid string) ([]biz.ContextVersion, error) {
+	var versions []biz.ContextVersion
+	tx := sessionDataSource.data.db.WithContext(ctx).
+		Where("session_uuid = ? and context_uuid = ?", sessionUuid, contextUuid).
+		Order("version, position").
+		Find(&versions)
+	return versions, tx.Error
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"github.com/qx66/pedant/internal/biz"
+	"gorm.io/gorm"
+)
+
+type usageDataSource struct {
+	data *Data
+}
+
+func NewUsageDataSource(data *Data) biz.UsageRepo {
+	return &usageDataSource{
+		data: data,
+	}
+}
+
+// 日期使用数据库时区
+
+const usageDay = "from_unixtime(%s.create_time, '%%Y-%%m-%%d')"
+
+func (usageDataSource *usageDataSource) ListUsage(ctx context.Context, filter biz.UsageFilter) ([]biz.Usage, error) {
+	var usages []biz.Usage
+	
+	// 对话: session_context 中没有用户，通过 session 关联
+	// 重新生成或编辑前的对话已经计费，移动到 session_context_version 后依然需要统计
+	// 导入的对话没有调用大模型，分支复制的对话已经在原会话中统计，都不计入用量
+	contexts := usageDataSource.data.db.
+		Raw("select session_uuid, llm, model, prompt_tokens, completion_tokens, total_tokens, create_time from session_context where source = '' " +
+			"union all " +
+			"select session_uuid, llm, model, prompt_tokens, completion_tokens, total_tokens, create_time from session_context_version where source = ''")
+	
+	var chatUsages []biz.Usage
+	tx := usageDataSource.where(usageDataSource.data.db.WithContext(ctx).
+		Table("(?) sc", contexts).
+		Joins("join session s on s.uuid = sc.session_uuid").
+		Select("s.user_uuid, sc.llm, sc.model, "+fmt.Sprintf(usageDay, "sc")+" as day, count(*) as requests, "+
+			"sum(sc.prompt_tokens) as prompt_tokens, sum(sc.completion_tokens) as completion_tokens, sum(sc.total_tokens) as total_tokens").
+		Group("s.user_uuid, sc.llm, sc.model, day"), "sc", "s", filter).
+		Scan(&chatUsages)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	usages = appendUsage(usages, chatUsages, biz.UsageKindChat)
+	
+	// 多模态
+	var multiModalUsages []biz.Usage
+	tx = usageDataSource.where(usageDataSource.data.db.WithContext(ctx).
+		Table("multi_modal mm").
+		Select("mm.user_uuid, mm.llm, "+fmt.Sprintf(usageDay, "mm")+" as day, count(*) as requests, "+
+			"sum(mm.prompt_tokens) as prompt_tokens, sum(mm.completion_tokens) as completion_tokens, sum(mm.total_tokens) as total_tokens").
+		Group("mm.user_uuid, mm.llm, day"), "mm", "mm", filter).
+		Scan(&multiModalUsages)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	usages = appendUsage(usages, multiModalUsages, biz.UsageKindMultiModal)
+	
+	// 文生图: image 表中只有 prompt_tokens 和 total_tokens
+	var imageUsages []biz.Usage
+	tx = usageDataSource.where(usageDataSource.data.db.WithContext(ctx).
+		Table("image i").
+		Select("i.user_uuid, "+fmt.Sprintf(usageDay, "i")+" as day, count(*) as requests, "+
+			"sum(i.prompt_tokens) as prompt_tokens, sum(i.total_tokens) as total_tokens").
+		Group("i.user_uuid, day"), "i", "i", filter).
+		Scan(&imageUsages)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	usages = appendUsage(usages, imageUsages, biz.UsageKindImage)
+	
+	return usages, nil
+}
+
+func (usageDataSource *usageDataSource) where(db *gorm.DB, table, userTable string, filter biz.UsageFilter) *gorm.DB {
+	db = db.Where(table+".create_time >= ? and "+table+".create_time < ?", filter.StartTime, filter.EndTime)
+	if filter.UserUuid != "" {
+		db = db.Where(userTable+".user_uuid = ?", filter.UserUuid)
+	}
+	return db
+}
+
+func appendUsage(usages []biz.Usage, rows []biz.Usage, kind string) []biz.Usage {
+	for _, row := range rows {
+		row.Kind = kind
+		usages = append(usages, row)
+	}
+	return usages
+}
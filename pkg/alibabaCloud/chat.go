@@ -0,0 +1,126 @@
+package alibabaCloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/qx66/pedant/pkg/chat"
+	"io"
+	"net/http"
+)
+
+// 通义千问 - OpenAI 兼容模式
+// https://help.aliyun.com/zh/model-studio/developer-reference/compatibility-of-openai-with-dashscope
+
+const (
+	ChatModelQwenTurbo = "qwen-turbo"
+	ChatModelQwenPlus  = "qwen-plus"
+	ChatModelQwenMax   = "qwen-max"
+)
+
+type ChatReq struct {
+	Model         string         `json:"model,omitempty"`
+	Messages      []ChatMessage  `json:"messages,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"` // 范围 [0, 2)
+	TopP          *float64       `json:"top_p,omitempty"`       // 范围 (0, 1.0]
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	Seed          *int64         `json:"seed,omitempty"`
+}
+
+type ChatMessage struct {
+	Role    string `json:"role,omitempty"` // system, user, assistant
+	Content string `json:"content,omitempty"`
+}
+
+type ChatResponse struct {
+	Id      string               `json:"id"`
+	Object  string               `json:"object"`
+	Created int                  `json:"created"`
+	Model   string               `json:"model"`
+	Choices []ChatResponseChoice `json:"choices"`
+	Usage   StreamResponseUsage  `json:"usage"`
+}
+
+type ChatResponseChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+func (client *Client) ChatCompletions(ctx context.Context, chatReq ChatReq) (ChatResponse, error) {
+	var chatResponse ChatResponse
+	chatReq.Stream = false
+	chatReq.StreamOptions = nil
+	
+	chatReqByte, err := json.Marshal(chatReq)
+	if err != nil {
+		return chatResponse, err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseUrl+fullModelApi, bytes.NewBuffer(chatReqByte))
+	if err != nil {
+		return chatResponse, err
+	}
+	
+	req.Header.Add("Content-Type", defaultContentType)
+	req.Header.Add("Authorization", client.authorization)
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return chatResponse, err
+	}
+	
+	defer resp.Body.Close()
+	
+	respByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return chatResponse, err
+	}
+	
+	if resp.StatusCode != http.StatusOK {
+		return chatResponse, chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
+	}
+	
+	err = json.Unmarshal(respByte, &chatResponse)
+	return chatResponse, err
+}
+
+// ChatCompletionsStream 流式请求，返回格式与 ImageCompletions 相同，开启 include_usage 后最后一条数据的 choices 为空，只包含 usage
+
+func (client *Client) ChatCompletionsStream(ctx context.Context, chatReq ChatReq, onResponse func(StreamResponse) error) error {
+	chatReq.Stream = true
+	chatReq.StreamOptions = &StreamOptions{IncludeUsage: true}
+	
+	chatReqByte, err := json.Marshal(chatReq)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseUrl+fullModelApi, bytes.NewBuffer(chatReqByte))
+	if err != nil {
+		return err
+	}
+	
+	req.Header.Add("Content-Type", defaultContentType)
+	req.Header.Add("Authorization", client.authorization)
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		respByte, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
+	}
+	
+	return readStream(resp.Body, onResponse)
+}
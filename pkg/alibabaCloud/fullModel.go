@@ -1,33 +1,48 @@
 package alibabaCloud
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/qx66/pedant/pkg/sse"
+	"io"
 	"net/http"
 	"strings"
 )
 
 const (
-	fullModelApi       = "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions"
+	DefaultBaseUrl     = "https://dashscope.aliyuncs.com/compatible-mode/v1"
+	fullModelApi       = "/chat/completions"
 	defaultModel       = "qwen-omni-turbo"
 	defaultContentType = "application/json"
 )
 
 type Client struct {
 	authorization string
+	baseUrl       string
 	cli           *http.Client
 }
 
-func NewClient(apiKey string) *Client {
+// NewClient baseUrl 为空时使用 DefaultBaseUrl，cli 为空时使用 http.DefaultClient
+
+func NewClient(apiKey, baseUrl string, cli *http.Client) *Client {
 	if apiKey == "" {
 		panic("apiKey is null")
 	}
 	
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	
 	return &Client{
 		authorization: fmt.Sprintf("Bearer %s", apiKey),
-		cli:           http.DefaultClient,
+		baseUrl:       strings.TrimSuffix(baseUrl, "/"),
+		cli:           cli,
 	}
 }
 
@@ -100,6 +115,19 @@ type StreamResponseUsagePromptTokensDetails struct {
 	ImageTokens int `json:"image_tokens"`
 }
 
+// 解析 SSE 返回的数据，choices 可能为空 (include_usage 的最后一条数据)
+
+func readStream(body io.Reader, onResponse func(StreamResponse) error) error {
+	return sse.Read(body, func(data []byte) error {
+		r := StreamResponse{}
+		err := json.Unmarshal(data, &r)
+		if err != nil {
+			return err
+		}
+		return onResponse(r)
+	})
+}
+
 const (
 	lotteryTicketSystem = `
 你需要提取出彩票类型名字(name,为string类型)、期号(issue,为string类型)、期号数字(issueNumber,为int类型)、开奖日期(drawDate,为string类型)、单式票(tickets,为array string类型)、金额(amount,为string类型)、金额值(amountNumber,为int类型)
@@ -119,7 +147,7 @@ type LotteryTicket struct {
 	AmountNumber int      `json:"amountNumber"`
 }
 
-func (client *Client) ImageCompletions(imageUrl string, userTextContent string) {
+func (client *Client) ImageCompletions(ctx context.Context, imageUrl string, userTextContent string) {
 	imageModelReq := ImageModelReq{
 		Model: defaultModel,
 		Messages: []ImageModelMessage{
@@ -162,7 +190,7 @@ func (client *Client) ImageCompletions(imageUrl string, userTextContent string)
 		return
 	}
 	
-	req, err := http.NewRequest(http.MethodPost, fullModelApi, bytes.NewBuffer(imageModelReqByte))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseUrl+fullModelApi, bytes.NewBuffer(imageModelReqByte))
 	if err != nil {
 		return
 	}
@@ -177,38 +205,20 @@ func (client *Client) ImageCompletions(imageUrl string, userTextContent string)
 	
 	defer resp.Body.Close()
 	
-	reader := bufio.NewReader(resp.Body)
 	var contents []string
 	
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			break // 读取完毕或发生错误
-		}
-		// 解析返回的 JSON 数据
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "data:") {
-			data := strings.TrimPrefix(line, "data:")
-			//fmt.Println(data) // 输出 AI 响应
-			r := StreamResponse{}
-			err = json.Unmarshal([]byte(data), &r)
-			if err != nil {
-				fmt.Println("json unmarshal failed.")
-				return
-			}
-			
-			if r.Choices[0].FinishReason == "stop" {
-				break
-			}
-			
-			//fmt.Print(r.Choices[0].Delta.Content)
-			contents = append(contents, r.Choices[0].Delta.Content)
+	err = readStream(resp.Body, func(r StreamResponse) error {
+		if len(r.Choices) == 0 {
+			return nil
 		}
 		
-		// 处理 OpenAI 的流式结束标记
-		if line == "data: [DONE]" {
-			break
-		}
+		//fmt.Print(r.Choices[0].Delta.Content)
+		contents = append(contents, r.Choices[0].Delta.Content)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("read stream failed.")
+		return
 	}
 	
 	content := strings.Join(contents, "")
@@ -0,0 +1,118 @@
+package alibabaCloud
+
+import (
+	"context"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+)
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client *Client
+}
+
+func NewProvider(client *Client) *Provider {
+	return &Provider{
+		client: client,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 2, MaxExclusive: true},
+		TopP:        chat.Range{Min: 0, Max: 1, MinExclusive: true},
+		MaxTokens:   8192,
+		MaxStop:     -1,
+		Seed:        true,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	resp, err := provider.client.ChatCompletions(ctx, generateChatReq(req))
+	if err != nil {
+		return chat.Response{}, err
+	}
+	
+	if len(resp.Choices) == 0 {
+		return chat.Response{}, chat.ErrEmptyResponse
+	}
+	
+	return chat.Response{
+		Model:        resp.Model,
+		Content:      resp.Choices[0].Message.Content,
+		FinishReason: resp.Choices[0].FinishReason,
+		Usage: chat.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	body := generateChatReq(req)
+	response := chat.Response{Model: body.Model}
+	var content strings.Builder
+	
+	err := provider.client.ChatCompletionsStream(ctx, body, func(resp StreamResponse) error {
+		if resp.Usage.TotalTokens != 0 {
+			response.Usage = chat.Usage{
+				PromptTokens:     resp.Usage.PromptTokens,
+				CompletionTokens: resp.Usage.CompletionTokens,
+				TotalTokens:      resp.Usage.TotalTokens,
+			}
+		}
+		
+		if len(resp.Choices) == 0 {
+			return nil
+		}
+		
+		if resp.Choices[0].FinishReason != "" {
+			response.FinishReason = resp.Choices[0].FinishReason
+		}
+		
+		delta := resp.Choices[0].Delta.Content
+		if delta == "" {
+			return nil
+		}
+		content.WriteString(delta)
+		return onDelta(delta)
+	})
+	
+	response.Content = content.String()
+	return response, err
+}
+
+func generateChatReq(req chat.Request) ChatReq {
+	body := ChatReq{
+		Model: req.Model,
+	}
+	
+	if body.Model == "" {
+		body.Model = ChatModelQwenPlus
+	}
+	
+	if req.System != "" {
+		body.Messages = append(body.Messages, ChatMessage{
+			Role:    chat.RoleSystem,
+			Content: req.System,
+		})
+	}
+	
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, ChatMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	
+	body.Temperature = req.Options.Temperature
+	body.TopP = req.Options.TopP
+	body.MaxTokens = req.Options.MaxTokens
+	body.Stop = req.Options.Stop
+	body.Seed = req.Options.Seed
+	return body
+}
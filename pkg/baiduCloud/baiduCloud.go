@@ -1,10 +1,17 @@
 package baiduCloud
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/startopsz/rule/pkg/http"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/qx66/pedant/pkg/sse"
+	"io"
+	nHttp "net/http"
+	"strings"
+	"time"
 )
 
 const (
@@ -13,11 +20,39 @@ const (
 )
 
 const (
-	stableDiffusionXLImageApi = "https://aip.baidubce.com/rpc/2.0/ai_custom/v1/wenxinworkshop/text2image/sd_xl"
-	ernieBotApi               = "https://aip.baidubce.com/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/eb-instant"
-	ernieBot4Api              = "https://aip.baidubce.com/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions_pro"
+	DefaultBaseUrl            = "https://aip.baidubce.com"
+	accessTokenApi            = "/oauth/2.0/token"
+	stableDiffusionXLImageApi = "/rpc/2.0/ai_custom/v1/wenxinworkshop/text2image/sd_xl"
+	ernieBotApi               = "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/eb-instant"
+	ernieBot4Api              = "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions_pro"
 )
 
+// Client baseUrl 可以指向内部网关，代理等通过 cli 设置，包级别的函数使用默认配置
+
+type Client struct {
+	baseUrl string
+	cli     *nHttp.Client
+}
+
+// NewClient baseUrl 为空时使用 DefaultBaseUrl，cli 为空时使用 http.DefaultClient
+
+func NewClient(baseUrl string, cli *nHttp.Client) *Client {
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	
+	if cli == nil {
+		cli = nHttp.DefaultClient
+	}
+	
+	return &Client{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		cli:     cli,
+	}
+}
+
+var defaultClient = NewClient("", nil)
+
 type AccessToken struct {
 	RefreshToken  string `json:"refresh_token,omitempty"`
 	ExpiresIn     int    `json:"expires_in,omitempty"`
@@ -30,29 +65,20 @@ type AccessToken struct {
 // 获取百度千帆调用接口的 AccessToken
 // access_token默认有效期30天，单位是秒，生产环境注意及时刷新。
 
-func GetQianFanAccessToken(apiKey, secretKey string) (AccessToken, error) {
+func GetQianFanAccessToken(ctx context.Context, apiKey, secretKey string) (AccessToken, error) {
+	return defaultClient.GetQianFanAccessToken(ctx, apiKey, secretKey)
+}
+
+func (client *Client) GetQianFanAccessToken(ctx context.Context, apiKey, secretKey string) (AccessToken, error) {
 	var accessToken AccessToken
-	baseUrl := "https://aip.baidubce.com/oauth/2.0/token"
-	url := fmt.Sprintf("%s?grant_type=client_credentials&client_id=%s&client_secret=%s", baseUrl, apiKey, secretKey)
+	url := fmt.Sprintf("%s%s?grant_type=client_credentials&client_id=%s&client_secret=%s", client.baseUrl, accessTokenApi, apiKey, secretKey)
 	
-	req := http.Req{
-		Method:  http.Post,
-		Url:     url,
-		Timeout: 5,
-	}
-	
-	resp, err := req.Do()
+	respByte, err := client.post(ctx, url, nil, 5*time.Second)
 	if err != nil {
 		return accessToken, err
 	}
 	
-	if resp.StatusCode != 200 {
-		return accessToken, errors.New(
-			fmt.Sprintf("http status: %d, message: %s", resp.StatusCode, string(resp.Body)),
-		)
-	}
-	
-	err = json.Unmarshal(resp.Body, &accessToken)
+	err = json.Unmarshal(respByte, &accessToken)
 	if err != nil {
 		return accessToken, err
 	}
@@ -61,14 +87,15 @@ func GetQianFanAccessToken(apiKey, secretKey string) (AccessToken, error) {
 }
 
 type ERNIEBotTurboReq struct {
-	Messages []ERNIEBotTurboMessage `json:"messages,omitempty"`
-	Stream   bool                   `json:"stream,omitempty"` // 默认false
-	System   string                 `json:"system,omitempty"`
-	UserId   string                 `json:"user_id,omitempty"`
-	//Temperature  float32                `json:"temperature,omitempty"`   // 默认0.95，范围 (0, 1.0]，不能为0, 建议top_p和temperature不要同时更改
-	//TopP         float32                `json:"top_p,omitempty"`         // 默认0.8，取值范围 [0, 1.0], 建议top_p和temperature不要同时更改
-	//PenaltyScore bool                   `json:"penalty_score,omitempty"` // 通过对已生成的token增加惩罚，减少重复生成的现象。 默认1.0，取值范围：[1.0, 2.0]
-	//UserId       string                 `json:"user_id,omitempty"`
+	Messages        []ERNIEBotTurboMessage `json:"messages,omitempty"`
+	Stream          bool                   `json:"stream,omitempty"` // 默认false
+	System          string                 `json:"system,omitempty"`
+	UserId          string                 `json:"user_id,omitempty"`
+	Temperature     *float64               `json:"temperature,omitempty"`       // 默认0.95，范围 (0, 1.0]，不能为0, 建议top_p和temperature不要同时更改
+	TopP            *float64               `json:"top_p,omitempty"`             // 默认0.8，取值范围 [0, 1.0], 建议top_p和temperature不要同时更改
+	PenaltyScore    *float64               `json:"penalty_score,omitempty"`     // 通过对已生成的token增加惩罚，减少重复生成的现象。 默认1.0，取值范围：[1.0, 2.0]
+	Stop            []string               `json:"stop,omitempty"`              // 生成停止标识，元素个数不超过4
+	MaxOutputTokens int                    `json:"max_output_tokens,omitempty"` // 最大输出token数，范围 [2, 2048]
 }
 
 type ERNIEBotTurboMessage struct {
@@ -84,6 +111,7 @@ type ERNIEBotTurboResponse struct {
 	SentenceId       int64                      `json:"sentence_id,omitempty"`        // 表示当前子句的序号。只有在流式接口模式下会返回该字段
 	IsEnd            bool                       `json:"is_end,omitempty"`             // 表示当前子句是否是最后一句。只有在流式接口模式下会返回该字段
 	IsTruncated      bool                       `json:"is_truncated,omitempty"`       // 当前生成的结果是否被截断
+	FinishReason     string                     `json:"finish_reason,omitempty"`      // 输出内容标识，normal：输出内容完全由大模型生成，stop：输出结果命中入参stop中指定的字段后被截断，length：达到了最大的token数，content_filter：输出内容被截断、兜底、替换为**等
 	Result           string                     `json:"result,omitempty"`             // 对话返回结果
 	NeedClearHistory bool                       `json:"need_clear_history,omitempty"` // 表示用户输入是否存在安全，是否关闭当前会话，清理历史会话信息。 true：是，表示用户输入存在安全风险，建议关闭当前会话，清理历史会话信息。 false：否，表示用户输入无安全风险
 	Usage            ERNIEBotTurboResponseUsage `json:"usage,omitempty"`              // token统计信息，token数 = 汉字数+单词数*1.3 （仅为估算逻辑）
@@ -97,9 +125,13 @@ type ERNIEBotTurboResponseUsage struct {
 	TotalTokens      int `json:"total_tokens,omitempty"`      // tokens总数
 }
 
-func SendERNIEBotTurbo(accessToken string, body ERNIEBotTurboReq) (ERNIEBotTurboResponse, error) {
+func SendERNIEBotTurbo(ctx context.Context, accessToken string, body ERNIEBotTurboReq) (ERNIEBotTurboResponse, error) {
+	return defaultClient.SendERNIEBotTurbo(ctx, accessToken, body)
+}
+
+func (client *Client) SendERNIEBotTurbo(ctx context.Context, accessToken string, body ERNIEBotTurboReq) (ERNIEBotTurboResponse, error) {
 	var ernieBotResp ERNIEBotTurboResponse
-	url := fmt.Sprintf("%s?access_token=%s", ernieBot4Api, accessToken)
+	url := fmt.Sprintf("%s%s?access_token=%s", client.baseUrl, ernieBot4Api, accessToken)
 	
 	bodyByte, err := json.Marshal(body)
 	if err != nil {
@@ -107,39 +139,82 @@ func SendERNIEBotTurbo(accessToken string, body ERNIEBotTurboReq) (ERNIEBotTurbo
 	}
 	
 	//
-	req := http.Req{
-		Method:  http.Post,
-		Url:     url,
-		Body:    bodyByte,
-		Timeout: 120,
-	}
-	
-	resp, err := req.Do()
+	respByte, err := client.post(ctx, url, bodyByte, 120*time.Second)
 	if err != nil {
 		return ernieBotResp, err
 	}
-	//
-	
-	if resp.StatusCode != 200 {
-		return ernieBotResp, errors.New(
-			fmt.Sprintf("http status: %d, message: %s", resp.StatusCode, string(resp.Body)),
-		)
-	}
 	
 	//
-	err = json.Unmarshal(resp.Body, &ernieBotResp)
+	err = json.Unmarshal(respByte, &ernieBotResp)
 	if err != nil {
 		return ernieBotResp, err
 	}
 	
 	if ernieBotResp.ErrorCode != 0 {
-		return ernieBotResp, errors.New(ernieBotResp.ErrorMsg)
+		return ernieBotResp, ernieBotError(ernieBotResp.ErrorCode, ernieBotResp.ErrorMsg)
 	}
 	
 	//
 	return ernieBotResp, nil
 }
 
+// SendERNIEBotTurboStream 流式请求，每收到一个子句调用一次 onResponse，is_end 为 true 表示最后一句
+
+func SendERNIEBotTurboStream(ctx context.Context, accessToken string, body ERNIEBotTurboReq, onResponse func(ERNIEBotTurboResponse) error) error {
+	return defaultClient.SendERNIEBotTurboStream(ctx, accessToken, body, onResponse)
+}
+
+func (client *Client) SendERNIEBotTurboStream(ctx context.Context, accessToken string, body ERNIEBotTurboReq, onResponse func(ERNIEBotTurboResponse) error) error {
+	url := fmt.Sprintf("%s%s?access_token=%s", client.baseUrl, ernieBot4Api, accessToken)
+	body.Stream = true
+	
+	bodyByte, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	
+	req, err := nHttp.NewRequestWithContext(ctx, nHttp.MethodPost, url, bytes.NewBuffer(bodyByte))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	// 请求失败时千帆返回的是普通的 json，而不是 event-stream
+	if resp.StatusCode != 200 || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		respByte, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		
+		var ernieBotResp ERNIEBotTurboResponse
+		if json.Unmarshal(respByte, &ernieBotResp) == nil && ernieBotResp.ErrorCode != 0 {
+			return ernieBotError(ernieBotResp.ErrorCode, ernieBotResp.ErrorMsg)
+		}
+		
+		return chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
+	}
+	
+	return sse.Read(resp.Body, func(data []byte) error {
+		var ernieBotResp ERNIEBotTurboResponse
+		err := json.Unmarshal(data, &ernieBotResp)
+		if err != nil {
+			return err
+		}
+		
+		if ernieBotResp.ErrorCode != 0 {
+			return ernieBotError(ernieBotResp.ErrorCode, ernieBotResp.ErrorMsg)
+		}
+		
+		return onResponse(ernieBotResp)
+	})
+}
+
 // https://cloud.baidu.com/doc/WENXINWORKSHOP/s/Klkqubb9w
 
 type StableDiffusionSize string
@@ -171,11 +246,13 @@ type StableDiffusionXLReq struct {
 }
 
 type StableDiffusionXLResponse struct {
-	Id      string                          `json:"id,omitempty"`      // 请求的id
-	Object  string                          `json:"object,omitempty"`  // 回包类型。image：图像生成返回
-	Created int64                           `json:"created,omitempty"` // 时间戳
-	Data    []StableDiffusionXLResponseData `json:"data,omitempty"`    // 生成图片结果
-	Usage   ERNIEBotTurboResponseUsage      `json:"usage,omitempty"`   // oken统计信息
+	Id        string                          `json:"id,omitempty"`         // 请求的id
+	Object    string                          `json:"object,omitempty"`     // 回包类型。image：图像生成返回
+	Created   int64                           `json:"created,omitempty"`    // 时间戳
+	Data      []StableDiffusionXLResponseData `json:"data,omitempty"`       // 生成图片结果
+	Usage     ERNIEBotTurboResponseUsage      `json:"usage,omitempty"`      // oken统计信息
+	ErrorCode int                             `json:"error_code,omitempty"` // 错误码
+	ErrorMsg  string                          `json:"error_msg,omitempty"`  // 错误描述信息
 }
 
 type StableDiffusionXLResponseData struct {
@@ -184,10 +261,33 @@ type StableDiffusionXLResponseData struct {
 	Index    int    `json:"index,omitempty"`     // 序号
 }
 
-func GenerateStableDiffusionXLImage(accessToken string, body StableDiffusionXLReq) (StableDiffusionXLResponse, error) {
+// ErrAccessTokenInvalid AccessToken 无效或过期 (110/111)，需要重新获取 AccessToken，不是 apikey 的问题
+
+var ErrAccessTokenInvalid = errors.New("baiduCloud: access token is invalid or expired")
+
+// 千帆请求失败时 http 状态码为 200，通过 error_code 区分，限流和服务端错误转换为对应的状态码以便重试
+// https://cloud.baidu.com/doc/WENXINWORKSHOP/s/tlmyncueh
+
+func ernieBotError(errorCode int, errorMsg string) error {
+	switch errorCode {
+	case 110, 111:
+		return fmt.Errorf("%w: %s", ErrAccessTokenInvalid, errorMsg)
+	case 4, 17, 18, 336501, 336502:
+		return &chat.StatusError{StatusCode: 429, Body: errorMsg}
+	case 2, 336100:
+		return &chat.StatusError{StatusCode: 503, Body: errorMsg}
+	}
+	return errors.New(errorMsg)
+}
+
+func GenerateStableDiffusionXLImage(ctx context.Context, accessToken string, body StableDiffusionXLReq) (StableDiffusionXLResponse, error) {
+	return defaultClient.GenerateStableDiffusionXLImage(ctx, accessToken, body)
+}
+
+func (client *Client) GenerateStableDiffusionXLImage(ctx context.Context, accessToken string, body StableDiffusionXLReq) (StableDiffusionXLResponse, error) {
 	var response StableDiffusionXLResponse
 	
-	url := fmt.Sprintf("%s?access_token=%s", stableDiffusionXLImageApi, accessToken)
+	url := fmt.Sprintf("%s%s?access_token=%s", client.baseUrl, stableDiffusionXLImageApi, accessToken)
 	
 	bodyByte, err := json.Marshal(body)
 	if err != nil {
@@ -195,27 +295,51 @@ func GenerateStableDiffusionXLImage(accessToken string, body StableDiffusionXLRe
 	}
 	
 	//
-	req := http.Req{
-		Method:  http.Post,
-		Url:     url,
-		Body:    bodyByte,
-		Timeout: 120,
+	respByte, err := client.post(ctx, url, bodyByte, 120*time.Second)
+	if err != nil {
+		return response, err
 	}
 	
-	resp, err := req.Do()
+	//
+	err = json.Unmarshal(respByte, &response)
 	if err != nil {
 		return response, err
 	}
+	
+	if response.ErrorCode != 0 {
+		return response, ernieBotError(response.ErrorCode, response.ErrorMsg)
+	}
+	
 	//
+	return response, nil
+}
+
+// 非流式请求，ctx 取消或超过 timeout 时中止请求
+
+func (client *Client) post(ctx context.Context, url string, body []byte, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	
+	req, err := nHttp.NewRequestWithContext(ctx, nHttp.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	respByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
 	
 	if resp.StatusCode != 200 {
-		return response, errors.New(
-			fmt.Sprintf("http status: %d, message: %s", resp.StatusCode, string(resp.Body)),
-		)
+		return nil, chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
 	}
 	
-	//
-	err = json.Unmarshal(resp.Body, &response)
-	//
-	return response, err
+	return respByte, nil
 }
@@ -48,7 +48,7 @@ func ChatV2(ctx context.Context, appid, authorization string, reqBody ChatReq) e
 		Timeout: time.Duration(120) * time.Second,
 	}
 	
-	req, err := http.NewRequest(http.MethodPost, chatV2Url, bytes.NewBuffer(bodyByte))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chatV2Url, bytes.NewBuffer(bodyByte))
 	//
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authorization))
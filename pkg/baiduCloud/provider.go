@@ -0,0 +1,144 @@
+package baiduCloud
+
+import (
+	"context"
+	"errors"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+)
+
+// AccessTokenSource 调用千帆接口使用的 AccessToken，由调用方负责缓存与刷新
+
+type AccessTokenSource interface {
+	Get(ctx context.Context) (string, error)
+	Invalidate() // 删除缓存的 AccessToken，下次 Get 时重新获取
+}
+
+// WithAccessToken 使用 AccessToken 调用 fn，AccessToken 无效或过期时删除缓存，重新获取后重试一次
+// 重新获取的 AccessToken 依然无效时返回 401，由 apikey 池暂时摘除该 apikey
+
+func WithAccessToken(ctx context.Context, accessToken AccessTokenSource, fn func(token string) error) error {
+	for attempt := 0; ; attempt++ {
+		token, err := accessToken.Get(ctx)
+		if err != nil {
+			return err
+		}
+		
+		err = fn(token)
+		if !errors.Is(err, ErrAccessTokenInvalid) {
+			return err
+		}
+		
+		accessToken.Invalidate()
+		if attempt > 0 {
+			return &chat.StatusError{StatusCode: 401, Body: err.Error()}
+		}
+	}
+}
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client      *Client
+	accessToken AccessTokenSource
+}
+
+func NewProvider(client *Client, accessToken AccessTokenSource) *Provider {
+	return &Provider{
+		client:      client,
+		accessToken: accessToken,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 1, MinExclusive: true},
+		TopP:        chat.Range{Min: 0, Max: 1},
+		MaxTokens:   2048,
+		MaxStop:     4,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	var resp ERNIEBotTurboResponse
+	err := WithAccessToken(ctx, provider.accessToken, func(token string) error {
+		var err error
+		resp, err = provider.client.SendERNIEBotTurbo(ctx, token, generateERNIEBotTurboReq(req))
+		return err
+	})
+	if err != nil {
+		return chat.Response{}, err
+	}
+	
+	return chat.Response{
+		Content:      resp.Result,
+		FinishReason: resp.FinishReason,
+		Usage: chat.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	var response chat.Response
+	var content strings.Builder
+	
+	// AccessToken 失效的错误在第一个子句之前返回，重试时还没有输出内容
+	err := WithAccessToken(ctx, provider.accessToken, func(token string) error {
+		return provider.client.SendERNIEBotTurboStream(ctx, token, generateERNIEBotTurboReq(req), provider.onStreamResponse(&response, &content, onDelta))
+	})
+	
+	response.Content = content.String()
+	return response, err
+}
+
+func (provider *Provider) onStreamResponse(response *chat.Response, content *strings.Builder, onDelta func(delta string) error) func(resp ERNIEBotTurboResponse) error {
+	return func(resp ERNIEBotTurboResponse) error {
+		// usage 为截止到当前子句的统计，以最后一条为准
+		response.Usage = chat.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+		
+		if resp.FinishReason != "" {
+			response.FinishReason = resp.FinishReason
+		}
+		
+		if resp.Result == "" {
+			return nil
+		}
+		content.WriteString(resp.Result)
+		return onDelta(resp.Result)
+	}
+}
+
+func generateERNIEBotTurboReq(req chat.Request) ERNIEBotTurboReq {
+	body := ERNIEBotTurboReq{
+		System: req.System,
+		Stream: false,
+	}
+	
+	for _, message := range req.Messages {
+		// 千帆的 system 只能通过 System 字段设置
+		if message.Role == chat.RoleSystem {
+			body.System = message.Content
+			continue
+		}
+		
+		body.Messages = append(body.Messages, ERNIEBotTurboMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	
+	body.Temperature = req.Options.Temperature
+	body.TopP = req.Options.TopP
+	body.Stop = req.Options.Stop
+	body.MaxOutputTokens = req.Options.MaxTokens
+	return body
+}
@@ -0,0 +1,88 @@
+package baiduCloud
+
+import (
+	"context"
+	"errors"
+	"github.com/qx66/pedant/pkg/chat"
+	"testing"
+)
+
+type fakeAccessToken struct {
+	tokens      []string
+	gets        int
+	invalidates int
+}
+
+func (fake *fakeAccessToken) Get(ctx context.Context) (string, error) {
+	token := fake.tokens[min(fake.gets, len(fake.tokens)-1)]
+	fake.gets++
+	return token, nil
+}
+
+func (fake *fakeAccessToken) Invalidate() {
+	fake.invalidates++
+}
+
+func TestWithAccessToken(t *testing.T) {
+	tests := []struct {
+		name            string
+		results         map[string]error // token -> fn 的返回值
+		wantStatus      int              // 期望返回的 StatusError 状态码，0 表示不是 StatusError
+		wantErr         bool
+		wantGets        int
+		wantInvalidates int
+	}{
+		{
+			name:     "成功",
+			results:  map[string]error{"old": nil},
+			wantGets: 1,
+		},
+		{
+			name:            "AccessToken 过期后重新获取成功",
+			results:         map[string]error{"old": ernieBotError(111, "Access token expired"), "new": nil},
+			wantGets:        2,
+			wantInvalidates: 1,
+		},
+		{
+			name:            "重新获取后依然无效返回 401",
+			results:         map[string]error{"old": ernieBotError(110, "Access token invalid or no longer valid"), "new": ernieBotError(110, "Access token invalid or no longer valid")},
+			wantStatus:      401,
+			wantErr:         true,
+			wantGets:        2,
+			wantInvalidates: 2,
+		},
+		{
+			name:       "其他错误不重新获取",
+			results:    map[string]error{"old": ernieBotError(18, "QPS limit")},
+			wantStatus: 429,
+			wantErr:    true,
+			wantGets:   1,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeAccessToken{tokens: []string{"old", "new"}}
+			err := WithAccessToken(context.Background(), source, func(token string) error {
+				return tt.results[token]
+			})
+			
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			
+			var statusErr *chat.StatusError
+			status := 0
+			if errors.As(err, &statusErr) {
+				status = statusErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			
+			if source.gets != tt.wantGets || source.invalidates != tt.wantInvalidates {
+				t.Errorf("gets = %d, invalidates = %d, want %d, %d", source.gets, source.invalidates, tt.wantGets, tt.wantInvalidates)
+			}
+		})
+	}
+}
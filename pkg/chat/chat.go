@@ -0,0 +1,57 @@
+package chat
+
+import (
+	"context"
+	"errors"
+)
+
+// 统一的对话接口
+// 各个大模型厂商 (openai / gemini / ernieBot / ollama ...) 在各自的包中实现 Provider，
+// 业务层只依赖本包的数据结构，通过 Registry 按名称获取对应的 Provider。
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+var (
+	ErrEmptyResponse = errors.New("llm return empty response")
+	ErrBlocked       = errors.New("llm blocked the request") // 输入被厂商的安全策略拦截
+)
+
+type Message struct {
+	Role    string `json:"role,omitempty"` // system, user, assistant
+	Content string `json:"content,omitempty"`
+}
+
+type Request struct {
+	Model    string    `json:"model,omitempty"`  // 为空时使用各 Provider 的默认模型
+	System   string    `json:"system,omitempty"` // 系统指令，由各 Provider 转换为厂商原生的方式
+	Messages []Message `json:"messages,omitempty"`
+	Options  Options   `json:"options,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"promptTokens,omitempty"`
+	CompletionTokens int `json:"completionTokens,omitempty"`
+	TotalTokens      int `json:"totalTokens,omitempty"`
+}
+
+type Response struct {
+	Model        string `json:"model,omitempty"`
+	Content      string `json:"content,omitempty"`
+	FinishReason string `json:"finishReason,omitempty"` // 模型停止生成的原因，各厂商取值不同，原样返回
+	Usage        Usage  `json:"usage,omitempty"`
+}
+
+type Provider interface {
+	// Limits 返回厂商支持的采样参数范围，用于在请求前校验 Options
+	Limits() Limits
+	
+	Chat(ctx context.Context, req Request) (Response, error)
+	
+	// ChatStream 使用厂商的流式接口，每收到一段增量内容调用一次 onDelta
+	// 即使返回 error (比如 ctx 被取消)，Response 中也包含已经生成的部分内容
+	ChatStream(ctx context.Context, req Request, onDelta func(delta string) error) (Response, error)
+}
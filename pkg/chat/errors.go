@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// StatusError 厂商接口返回了非 200 的状态码
+
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // 响应头 Retry-After，没有时为 0
+}
+
+func NewStatusError(statusCode int, header http.Header, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: statusCode,
+		Body:       string(body),
+		RetryAfter: ParseRetryAfter(header.Get("Retry-After")),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	
+	d := time.Until(t)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// IsRetryable 判断错误是否可以重试或切换到其他大模型: 网络错误、超时、429 和 5xx
+// 客户端主动取消的请求不能重试
+
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
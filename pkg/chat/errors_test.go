@@ -0,0 +1,41 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "客户端取消", err: context.Canceled, want: false},
+		{name: "超时", err: context.DeadlineExceeded, want: true},
+		{name: "429", err: &StatusError{StatusCode: 429}, want: true},
+		{name: "500", err: &StatusError{StatusCode: 500}, want: true},
+		{name: "503", err: fmt.Errorf("chat: %w", &StatusError{StatusCode: 503}), want: true},
+		{name: "400", err: &StatusError{StatusCode: 400}, want: false},
+		{name: "401", err: &StatusError{StatusCode: 401}, want: false},
+		{name: "连接中断", err: io.ErrUnexpectedEOF, want: true},
+		{name: "连接被拒绝", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "连接被重置", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "DNS 错误", err: &net.DNSError{Err: "no such host", Name: "api.example.com"}, want: true},
+		{name: "其他错误", err: errors.New("invalid request"), want: false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
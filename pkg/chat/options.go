@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+// Options 采样参数，为空的字段使用厂商的默认值
+
+type Options struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+	MaxTokens   int      `json:"maxTokens,omitempty"` // 最大生成 token 数
+	Stop        []string `json:"stop,omitempty"`      // 遇到其中任意一个字符串时停止生成
+	Seed        *int64   `json:"seed,omitempty"`      // 随机种子，相同的种子和参数尽可能返回相同的结果
+}
+
+// Range 取值范围
+
+type Range struct {
+	Min          float64
+	Max          float64
+	MinExclusive bool // 不能等于最小值
+	MaxExclusive bool // 不能等于最大值
+}
+
+func (r Range) contains(v float64) bool {
+	if v < r.Min || (r.MinExclusive && v == r.Min) {
+		return false
+	}
+	
+	if v > r.Max || (r.MaxExclusive && v == r.Max) {
+		return false
+	}
+	
+	return true
+}
+
+func (r Range) String() string {
+	left, right := "[", "]"
+	if r.MinExclusive {
+		left = "("
+	}
+	if r.MaxExclusive {
+		right = ")"
+	}
+	return fmt.Sprintf("%s%g, %g%s", left, r.Min, r.Max, right)
+}
+
+// Limits 厂商支持的采样参数范围
+
+type Limits struct {
+	Temperature Range
+	TopP        Range
+	MaxTokens   int  // 0 表示不限制
+	MaxStop     int  // stop 最大数量，0 表示不支持，-1 表示不限制
+	Seed        bool // 是否支持 seed
+}
+
+// ValidateOptions 校验采样参数是否在厂商支持的范围内，返回的 error 包装了 ErrInvalidOptions
+
+func ValidateOptions(limits Limits, opts Options) error {
+	if opts.Temperature != nil && !limits.Temperature.contains(*opts.Temperature) {
+		return fmt.Errorf("%w: temperature must be in %s", ErrInvalidOptions, limits.Temperature)
+	}
+	
+	if opts.TopP != nil && !limits.TopP.contains(*opts.TopP) {
+		return fmt.Errorf("%w: topP must be in %s", ErrInvalidOptions, limits.TopP)
+	}
+	
+	if opts.MaxTokens < 0 {
+		return fmt.Errorf("%w: maxTokens must not be negative", ErrInvalidOptions)
+	}
+	
+	if limits.MaxTokens > 0 && opts.MaxTokens > limits.MaxTokens {
+		return fmt.Errorf("%w: maxTokens must not exceed %d", ErrInvalidOptions, limits.MaxTokens)
+	}
+	
+	if len(opts.Stop) > 0 {
+		if limits.MaxStop == 0 {
+			return fmt.Errorf("%w: stop is not supported", ErrInvalidOptions)
+		}
+		
+		if limits.MaxStop > 0 && len(opts.Stop) > limits.MaxStop {
+			return fmt.Errorf("%w: at most %d stop sequences", ErrInvalidOptions, limits.MaxStop)
+		}
+	}
+	
+	if opts.Seed != nil && !limits.Seed {
+		return fmt.Errorf("%w: seed is not supported", ErrInvalidOptions)
+	}
+	
+	return nil
+}
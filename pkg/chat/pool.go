@@ -0,0 +1,249 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 同一个厂商的多个 apikey 按权重轮询选择，认证失败或额度不足时暂时摘除该 apikey，并使用下一个 apikey 重试
+
+const (
+	StrategyRoundRobin       = "roundRobin"       // 平滑加权轮询
+	StrategyLeastRateLimited = "leastRateLimited" // 优先使用最久没有被限流的 apikey，相同时加权轮询
+)
+
+var (
+	AuthErrorCooldown      = 10 * time.Minute // 认证失败后摘除的时间
+	RateLimitErrorCooldown = time.Minute      // 限流或额度不足后摘除的时间
+)
+
+// KeyPoolMember apikey 及其对应的客户端，T 可以是 Provider 或厂商的其他客户端 (多模态、文生图)
+
+type KeyPoolMember[T any] struct {
+	Key    T
+	Weight int // 默认 1
+}
+
+type poolMember[T any] struct {
+	key           T
+	weight        int
+	current       int
+	disabledUntil time.Time
+	rateLimitedAt time.Time
+}
+
+// KeyPool 按策略选择 apikey，认证失败或额度不足时暂时摘除该 apikey，并使用下一个 apikey 重试
+
+type KeyPool[T any] struct {
+	mu       sync.Mutex
+	strategy string
+	members  []*poolMember[T]
+}
+
+func NewKeyPool[T any](strategy string, members ...KeyPoolMember[T]) *KeyPool[T] {
+	pool := &KeyPool[T]{
+		strategy: strategy,
+	}
+	
+	for _, member := range members {
+		weight := member.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		
+		pool.members = append(pool.members, &poolMember[T]{
+			key:    member.Key,
+			weight: weight,
+		})
+	}
+	
+	return pool
+}
+
+// Do 使用选择的 apikey 调用 call，需要摘除 apikey 时换下一个 apikey 重试，所有 apikey 都失败时返回最后一个错误
+
+func (pool *KeyPool[T]) Do(ctx context.Context, call func(key T) error) error {
+	var err error
+	tried := make(map[*poolMember[T]]bool)
+	
+	for {
+		member := pool.next(tried)
+		if member == nil {
+			return err
+		}
+		tried[member] = true
+		
+		err = call(member.key)
+		
+		// 客户端取消时不能换 apikey 重试
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		
+		cooldown, rateLimited, ok := credentialCooldown(err)
+		if !ok {
+			return err
+		}
+		pool.disable(member, cooldown, rateLimited)
+	}
+}
+
+type PoolMember struct {
+	Provider Provider
+	Weight   int // 默认 1
+}
+
+// Pool 将同一个厂商的多个 apikey (每个 apikey 一个 Provider) 组合为一个 Provider
+
+type Pool struct {
+	keys *KeyPool[Provider]
+}
+
+func NewPool(strategy string, members ...PoolMember) *Pool {
+	var keys []KeyPoolMember[Provider]
+	for _, member := range members {
+		keys = append(keys, KeyPoolMember[Provider]{Key: member.Provider, Weight: member.Weight})
+	}
+	
+	return &Pool{
+		keys: NewKeyPool(strategy, keys...),
+	}
+}
+
+// Limits 同一个厂商的参数范围相同
+
+func (pool *Pool) Limits() Limits {
+	return pool.keys.members[0].key.Limits()
+}
+
+func (pool *Pool) Chat(ctx context.Context, req Request) (Response, error) {
+	return pool.do(ctx, func(provider Provider) (Response, error) {
+		return provider.Chat(ctx, req)
+	})
+}
+
+func (pool *Pool) ChatStream(ctx context.Context, req Request, onDelta func(delta string) error) (Response, error) {
+	return pool.do(ctx, func(provider Provider) (Response, error) {
+		return provider.ChatStream(ctx, req, onDelta)
+	})
+}
+
+func (pool *Pool) do(ctx context.Context, call func(provider Provider) (Response, error)) (Response, error) {
+	var resp Response
+	var callErr error
+	
+	err := pool.keys.Do(ctx, func(provider Provider) error {
+		resp, callErr = call(provider)
+		
+		// 已经输出了内容时不能换 apikey 重试，返回 nil 结束选择，错误通过 callErr 返回
+		if resp.Content != "" {
+			return nil
+		}
+		return callErr
+	})
+	if err != nil {
+		return resp, err
+	}
+	return resp, callErr
+}
+
+// 选择下一个 apikey，全部被摘除时选择最早恢复的 apikey
+
+func (pool *KeyPool[T]) next(tried map[*poolMember[T]]bool) *poolMember[T] {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	
+	now := time.Now()
+	var candidates []*poolMember[T]
+	var earliest *poolMember[T]
+	
+	for _, member := range pool.members {
+		if tried[member] {
+			continue
+		}
+		
+		if now.Before(member.disabledUntil) {
+			if earliest == nil || member.disabledUntil.Before(earliest.disabledUntil) {
+				earliest = member
+			}
+			continue
+		}
+		candidates = append(candidates, member)
+	}
+	
+	if len(candidates) == 0 {
+		return earliest
+	}
+	
+	if pool.strategy == StrategyLeastRateLimited {
+		candidates = leastRateLimited(candidates)
+	}
+	
+	return smoothWeightedRoundRobin(candidates)
+}
+
+func (pool *KeyPool[T]) disable(member *poolMember[T], cooldown time.Duration, rateLimited bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	
+	now := time.Now()
+	member.disabledUntil = now.Add(cooldown)
+	if rateLimited {
+		member.rateLimitedAt = now
+	}
+}
+
+func leastRateLimited[T any](members []*poolMember[T]) []*poolMember[T] {
+	var result []*poolMember[T]
+	for _, member := range members {
+		if len(result) == 0 || member.rateLimitedAt.Before(result[0].rateLimitedAt) {
+			result = []*poolMember[T]{member}
+			continue
+		}
+		
+		if member.rateLimitedAt.Equal(result[0].rateLimitedAt) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// nginx 的平滑加权轮询算法
+
+func smoothWeightedRoundRobin[T any](members []*poolMember[T]) *poolMember[T] {
+	var best *poolMember[T]
+	total := 0
+	
+	for _, member := range members {
+		member.current += member.weight
+		total += member.weight
+		
+		if best == nil || member.current > best.current {
+			best = member
+		}
+	}
+	
+	best.current -= total
+	return best
+}
+
+// 认证失败 (401/403) 或限流、额度不足 (402/429) 时需要暂时摘除 apikey
+
+func credentialCooldown(err error) (time.Duration, bool, bool) {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false, false
+	}
+	
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return AuthErrorCooldown, false, true
+	case http.StatusPaymentRequired, http.StatusTooManyRequests:
+		return RateLimitErrorCooldown, true, true
+	}
+	
+	return 0, false, false
+}
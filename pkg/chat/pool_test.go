@@ -0,0 +1,244 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	resp  Response
+	err   error
+	calls int
+}
+
+func (fake *fakeProvider) Limits() Limits {
+	return Limits{}
+}
+
+func (fake *fakeProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	fake.calls++
+	return fake.resp, fake.err
+}
+
+func (fake *fakeProvider) ChatStream(ctx context.Context, req Request, onDelta func(delta string) error) (Response, error) {
+	return fake.Chat(ctx, req)
+}
+
+func TestKeyPoolWeight(t *testing.T) {
+	pool := NewKeyPool(StrategyRoundRobin,
+		KeyPoolMember[string]{Key: "a", Weight: 3},
+		KeyPoolMember[string]{Key: "b"},
+	)
+	
+	var keys string
+	for i := 0; i < 8; i++ {
+		err := pool.Do(context.Background(), func(key string) error {
+			keys += key
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+	
+	// 平滑加权轮询: 权重 3:1，且不会连续选择同一个 apikey 4 次
+	if keys != "aabaaaba" {
+		t.Errorf("keys = %s, want aabaaaba", keys)
+	}
+}
+
+func TestKeyPoolCooldown(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantKeys     string // a 失败后依次使用的 apikey
+		wantErr      bool
+		wantDisabled time.Duration // a 被摘除的时间，0 表示没有摘除
+	}{
+		{
+			name:         "认证失败摘除后使用下一个 apikey",
+			err:          &StatusError{StatusCode: 401},
+			wantKeys:     "ab",
+			wantDisabled: AuthErrorCooldown,
+		},
+		{
+			name:         "余额不足摘除后使用下一个 apikey",
+			err:          &StatusError{StatusCode: 402},
+			wantKeys:     "ab",
+			wantDisabled: RateLimitErrorCooldown,
+		},
+		{
+			name:         "限流摘除后使用下一个 apikey",
+			err:          &StatusError{StatusCode: 429},
+			wantKeys:     "ab",
+			wantDisabled: RateLimitErrorCooldown,
+		},
+		{
+			name:     "服务端错误不换 apikey",
+			err:      &StatusError{StatusCode: 500},
+			wantKeys: "a",
+			wantErr:  true,
+		},
+		{
+			name:     "网络错误不换 apikey",
+			err:      errors.New("connection reset"),
+			wantKeys: "a",
+			wantErr:  true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewKeyPool(StrategyRoundRobin,
+				KeyPoolMember[string]{Key: "a", Weight: 2},
+				KeyPoolMember[string]{Key: "b"},
+			)
+			
+			var keys string
+			err := pool.Do(context.Background(), func(key string) error {
+				keys += key
+				if key == "a" {
+					return tt.err
+				}
+				return nil
+			})
+			
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if keys != tt.wantKeys {
+				t.Errorf("keys = %s, want %s", keys, tt.wantKeys)
+			}
+			
+			disabled := time.Until(pool.members[0].disabledUntil)
+			if tt.wantDisabled == 0 && disabled > 0 {
+				t.Errorf("a disabled for %s, want not disabled", disabled)
+			}
+			if tt.wantDisabled > 0 && (disabled <= tt.wantDisabled-time.Second || disabled > tt.wantDisabled) {
+				t.Errorf("a disabled for %s, want %s", disabled, tt.wantDisabled)
+			}
+			
+			// 摘除期间不再选择 a
+			if tt.wantDisabled > 0 {
+				keys = ""
+				_ = pool.Do(context.Background(), func(key string) error {
+					keys += key
+					return nil
+				})
+				if keys != "b" {
+					t.Errorf("keys after cooldown = %s, want b", keys)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyPoolAllDisabled(t *testing.T) {
+	pool := NewKeyPool(StrategyRoundRobin,
+		KeyPoolMember[string]{Key: "a"},
+		KeyPoolMember[string]{Key: "b"},
+	)
+	
+	var keys string
+	err := pool.Do(context.Background(), func(key string) error {
+		keys += key
+		return &StatusError{StatusCode: 429}
+	})
+	
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 429 {
+		t.Fatalf("Do() error = %v, want status 429", err)
+	}
+	if keys != "ab" {
+		t.Errorf("keys = %s, want ab", keys)
+	}
+	
+	// 全部被摘除时选择最早恢复的 apikey
+	pool.members[1].disabledUntil = time.Now().Add(time.Second)
+	keys = ""
+	_ = pool.Do(context.Background(), func(key string) error {
+		keys += key
+		return nil
+	})
+	if keys != "b" {
+		t.Errorf("keys = %s, want b", keys)
+	}
+}
+
+func TestKeyPoolLeastRateLimited(t *testing.T) {
+	pool := NewKeyPool(StrategyLeastRateLimited,
+		KeyPoolMember[string]{Key: "a", Weight: 5},
+		KeyPoolMember[string]{Key: "b"},
+		KeyPoolMember[string]{Key: "c"},
+	)
+	
+	now := time.Now()
+	pool.members[0].rateLimitedAt = now.Add(-time.Minute)
+	pool.members[1].rateLimitedAt = now.Add(-time.Hour)
+	
+	// 没有被限流过的 c 优先，之后是最久没有被限流的 b
+	var keys string
+	for i := 0; i < 2; i++ {
+		_ = pool.Do(context.Background(), func(key string) error {
+			keys += key
+			return nil
+		})
+	}
+	if keys != "cc" {
+		t.Errorf("keys = %s, want cc", keys)
+	}
+	
+	pool.members[2].rateLimitedAt = now
+	keys = ""
+	_ = pool.Do(context.Background(), func(key string) error {
+		keys += key
+		return nil
+	})
+	if keys != "b" {
+		t.Errorf("keys = %s, want b", keys)
+	}
+}
+
+func TestKeyPoolCanceled(t *testing.T) {
+	pool := NewKeyPool(StrategyRoundRobin,
+		KeyPoolMember[string]{Key: "a"},
+		KeyPoolMember[string]{Key: "b"},
+	)
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	
+	var keys string
+	_ = pool.Do(ctx, func(key string) error {
+		keys += key
+		return &StatusError{StatusCode: 401}
+	})
+	if keys != "a" {
+		t.Errorf("keys = %s, want a", keys)
+	}
+}
+
+func TestPoolNoSwitchAfterContent(t *testing.T) {
+	a := &fakeProvider{resp: Response{Content: "半句"}, err: &StatusError{StatusCode: 429}}
+	b := &fakeProvider{resp: Response{Content: "完整"}}
+	pool := NewPool(StrategyRoundRobin, PoolMember{Provider: a, Weight: 5}, PoolMember{Provider: b})
+	
+	resp, err := pool.Chat(context.Background(), Request{})
+	
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 429 {
+		t.Fatalf("Chat() error = %v, want status 429", err)
+	}
+	if resp.Content != "半句" || b.calls != 0 {
+		t.Errorf("content = %s, b.calls = %d, want 半句, 0", resp.Content, b.calls)
+	}
+	
+	// 没有输出内容时换 apikey 重试
+	a.resp = Response{}
+	resp, err = pool.Chat(context.Background(), Request{})
+	if err != nil || resp.Content != "完整" || a.calls != 2 {
+		t.Errorf("Chat() = %s, %v, a.calls = %d, want 完整, nil, 2", resp.Content, err, a.calls)
+	}
+}
@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry 保存 名称 -> Provider 的映射，新增厂商只需要注册对应的适配器
+
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+	}
+}
+
+func (registry *Registry) Register(name string, provider Provider) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	
+	registry.providers[name] = provider
+}
+
+func (registry *Registry) Get(name string) (Provider, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	
+	provider, ok := registry.providers[name]
+	return provider, ok
+}
+
+func (registry *Registry) Names() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	
+	var names []string
+	for name := range registry.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 重试策略，使用带随机抖动的指数退避，服务端返回 Retry-After 时优先使用 Retry-After
+// 对话接口不是幂等的，只重试服务端明确没有处理请求的错误
+
+type RetryPolicy struct {
+	MaxAttempts int           // 总尝试次数 (包含第一次请求)，<= 1 时不重试
+	BaseDelay   time.Duration // 第一次重试的最大等待时间，之后每次翻倍
+	MaxDelay    time.Duration // 单次最大等待时间，Retry-After 超过该值时不再重试
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// Do 执行 fn，失败且可以安全重试时等待后重试
+
+func (policy RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !policy.wait(ctx, err, attempt) {
+			return err
+		}
+	}
+}
+
+// 判断是否还能重试，可以重试时等待退避时间
+
+func (policy RetryPolicy) wait(ctx context.Context, err error, attempt int) bool {
+	if attempt+1 >= policy.MaxAttempts || ctx.Err() != nil || !IsSafeToRetry(err) {
+		return false
+	}
+	
+	delay := policy.backoff(attempt)
+	
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if policy.MaxDelay > 0 && statusErr.RetryAfter > policy.MaxDelay {
+			return false
+		}
+		delay = statusErr.RetryAfter
+	}
+	
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// full jitter: 在 [0, min(MaxDelay, BaseDelay * 2^attempt)) 之间随机
+
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+	
+	delay := policy.BaseDelay
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if policy.MaxDelay > 0 && delay >= policy.MaxDelay {
+			delay = policy.MaxDelay
+			break
+		}
+	}
+	
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// IsSafeToRetry 判断失败的请求是否可以安全重试
+// 408/429/502/503/504 以及连接没有建立的错误，服务端都没有处理请求，重试不会重复扣费
+// 500、读超时、连接中断时服务端可能已经处理了请求，不重试 (仍然可以切换到其他大模型)
+
+func IsSafeToRetry(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// NewRetryProvider 为 Provider 增加重试
+// 流式输出时已经输出了内容就不再重试，避免客户端收到重复的内容
+
+func NewRetryProvider(provider Provider, policy RetryPolicy) Provider {
+	if policy.MaxAttempts <= 1 {
+		return provider
+	}
+	return &retryProvider{provider: provider, policy: policy}
+}
+
+type retryProvider struct {
+	provider Provider
+	policy   RetryPolicy
+}
+
+func (r *retryProvider) Limits() Limits {
+	return r.provider.Limits()
+}
+
+func (r *retryProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	return r.do(ctx, func() (Response, error) {
+		return r.provider.Chat(ctx, req)
+	})
+}
+
+func (r *retryProvider) ChatStream(ctx context.Context, req Request, onDelta func(delta string) error) (Response, error) {
+	return r.do(ctx, func() (Response, error) {
+		return r.provider.ChatStream(ctx, req, onDelta)
+	})
+}
+
+func (r *retryProvider) do(ctx context.Context, call func() (Response, error)) (Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := call()
+		if err == nil || resp.Content != "" || !r.policy.wait(ctx, err, attempt) {
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "空", value: "", min: 0, max: 0},
+		{name: "秒数", value: "3", min: 3 * time.Second, max: 3 * time.Second},
+		{name: "负数", value: "-1", min: 0, max: 0},
+		{name: "HTTP 日期", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "过去的日期", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: 0, max: 0},
+		{name: "无法解析", value: "soon", min: 0, max: 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("ParseRetryAfter(%q) = %s, want [%s, %s]", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestNewStatusError(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+	
+	err := NewStatusError(429, header, []byte("rate limited"))
+	if err.StatusCode != 429 || err.Body != "rate limited" || err.RetryAfter != 2*time.Second {
+		t.Errorf("NewStatusError() = %+v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			if delay := policy.backoff(attempt); delay < 0 || delay >= max {
+				t.Fatalf("backoff(%d) = %s, want [0, %s)", attempt, delay, max)
+			}
+		}
+	}
+	
+	if delay := (RetryPolicy{}).backoff(3); delay != 0 {
+		t.Errorf("backoff without BaseDelay = %s, want 0", delay)
+	}
+}
+
+func TestIsSafeToRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "客户端取消", err: context.Canceled, want: false},
+		{name: "超时", err: context.DeadlineExceeded, want: false},
+		{name: "408", err: &StatusError{StatusCode: 408}, want: true},
+		{name: "429", err: &StatusError{StatusCode: 429}, want: true},
+		{name: "500", err: &StatusError{StatusCode: 500}, want: false},
+		{name: "502", err: &StatusError{StatusCode: 502}, want: true},
+		{name: "503", err: &StatusError{StatusCode: 503}, want: true},
+		{name: "504", err: &StatusError{StatusCode: 504}, want: true},
+		{name: "建立连接失败", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "读取失败", err: &net.OpError{Op: "read", Err: errors.New("connection reset")}, want: false},
+		{name: "DNS 临时错误", err: &net.DNSError{IsTemporary: true}, want: true},
+		{name: "DNS 不存在", err: &net.DNSError{IsNotFound: true}, want: false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSafeToRetry(tt.err); got != tt.want {
+				t.Errorf("IsSafeToRetry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error // 每次调用返回的错误，超出时返回 nil
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "成功不重试",
+			wantCalls: 1,
+		},
+		{
+			name:      "503 后重试成功",
+			errs:      []error{&StatusError{StatusCode: 503}},
+			wantCalls: 2,
+		},
+		{
+			name:      "超过最大次数",
+			errs:      []error{&StatusError{StatusCode: 503}, &StatusError{StatusCode: 503}, &StatusError{StatusCode: 503}, &StatusError{StatusCode: 503}},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "500 不重试",
+			errs:      []error{&StatusError{StatusCode: 500}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "Retry-After 超过 MaxDelay 不重试",
+			errs:      []error{&StatusError{StatusCode: 429, RetryAfter: time.Minute}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "Retry-After 没有超过 MaxDelay 时重试",
+			errs:      []error{&StatusError{StatusCode: 429, RetryAfter: time.Millisecond}},
+			wantCalls: 2,
+		},
+	}
+	
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := policy.Do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryProviderNoRetryAfterContent(t *testing.T) {
+	provider := &fakeProvider{resp: Response{Content: "半句"}, err: &StatusError{StatusCode: 503}}
+	retry := NewRetryProvider(provider, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	
+	_, err := retry.ChatStream(context.Background(), Request{}, func(delta string) error {
+		return nil
+	})
+	if err == nil || provider.calls != 1 {
+		t.Errorf("ChatStream() error = %v, calls = %d, want error, 1", err, provider.calls)
+	}
+}
@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrContextLength = errors.New("context length exceeded")
+)
+
+// 各厂商上下文超长时返回的错误信息 (小写)
+var contextLengthMessages = []string{
+	"context_length_exceeded", // openai / deepseek
+	"maximum context length",  // openai / deepseek / ollama
+	"context length",
+	"input length",               // qwen: Range of input length should be ...
+	"the length of messages",     // ernieBot: the length of messages is too long
+	"prompt tokens limit",        // ernieBot
+	"exceeds the maximum number", // gemini: The input token count exceeds the maximum number of tokens allowed
+	"too many tokens",
+}
+
+// IsContextLengthError 判断大模型返回的错误是否因为上下文超长
+
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	
+	if errors.Is(err, ErrContextLength) {
+		return true
+	}
+	
+	msg := strings.ToLower(err.Error())
+	for _, m := range contextLengthMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// EstimateTokens 粗略估算文本的 token 数，不依赖各厂商的分词器
+// 中日韩文字按每个字 1 个 token，其他字符按每 4 个字符 1 个 token
+
+func EstimateTokens(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// EstimateMessageTokens 估算一条消息的 token 数，每条消息额外计算角色等格式开销
+
+func EstimateMessageTokens(message Message) int {
+	return EstimateTokens(message.Content) + 4
+}
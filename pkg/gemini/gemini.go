@@ -2,12 +2,12 @@ package gemini
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 	"io"
 	"os"
+	"strings"
+	"unicode"
 )
 
 type Region string
@@ -59,30 +59,20 @@ func NewClient(apiKey string) (*Client, error) {
 
 // 文本
 
-func (client *Client) Text(ctx context.Context, text string) error {
-	
+func (client *Client) Text(ctx context.Context, text string) (Response, error) {
 	model := client.cli.GenerativeModel("gemini-pro")
 	
 	resp, err := model.GenerateContent(ctx, genai.Text(text))
-	
-	fmt.Println(resp)
-	
 	if err != nil {
-		return err
+		return Response{}, err
 	}
 	
-	rb, err := json.MarshalIndent(resp, "", "")
-	if err != nil {
-		return err
-	}
-	
-	fmt.Println(string(rb))
-	return nil
+	return sdkResponse(resp), nil
 }
 
 // Gemini 提供了一个多模态模型 (gemini-pro-vision)，因此您可以同时输入文本和图片
 
-func (client *Client) Multimodal(ctx context.Context, imagePaths []string, text string) error {
+func (client *Client) Multimodal(ctx context.Context, imagePaths []string, text string) (Response, error) {
 	model := client.cli.GenerativeModel("gemini-pro-vision")
 	
 	var prompt []genai.Part
@@ -91,13 +81,14 @@ func (client *Client) Multimodal(ctx context.Context, imagePaths []string, text
 		//
 		imgF, err := os.Open(imagePath)
 		if err != nil {
-			return err
+			return Response{}, err
 		}
 		
 		//
 		imgData, err := io.ReadAll(imgF)
+		imgF.Close()
 		if err != nil {
-			return err
+			return Response{}, err
 		}
 		
 		//
@@ -115,37 +106,94 @@ func (client *Client) Multimodal(ctx context.Context, imagePaths []string, text
 	
 	resp, err := model.GenerateContent(ctx, prompt...)
 	if err != nil {
-		return err
-	}
-	
-	fmt.Println("resp: ", resp)
-	rb, err := json.MarshalIndent(resp, "", "")
-	if err != nil {
-		return err
+		return Response{}, err
 	}
 	
-	fmt.Println(string(rb))
-	
-	return nil
+	return sdkResponse(resp), nil
 }
 
 // 对话
 
-func (client *Client) Chat(ctx context.Context, content []*genai.Content, text string) error {
+func (client *Client) Chat(ctx context.Context, content []*genai.Content, text string) (Response, error) {
 	model := client.cli.GenerativeModel("gemini-pro")
 	cs := model.StartChat()
 	cs.History = content
 	resp, err := cs.SendMessage(ctx, genai.Text(text))
 	if err != nil {
-		return err
+		return Response{}, err
 	}
 	
-	fmt.Println("resp: ", resp)
-	rb, err := json.MarshalIndent(resp, "", "")
-	if err != nil {
-		return err
+	return sdkResponse(resp), nil
+}
+
+// 将 SDK 的响应转换为与 REST 接口相同的 Response
+// 用量来自 usageMetadata，没有返回时输入 tokens 为 0，输出 tokens 使用候选的 TokenCount
+
+func sdkResponse(resp *genai.GenerateContentResponse) Response {
+	var response Response
+	
+	if resp.PromptFeedback != nil {
+		response.PromptFeedback.BlockReason = sdkEnum(resp.PromptFeedback.BlockReason.String(), "BlockReason")
+		response.PromptFeedback.SafetyRatings = sdkSafetyRatings(resp.PromptFeedback.SafetyRatings)
+	}
+	
+	for _, candidate := range resp.Candidates {
+		c := Candidates{
+			Index:         int(candidate.Index),
+			FinishReason:  sdkEnum(candidate.FinishReason.String(), "FinishReason"),
+			SafetyRatings: sdkSafetyRatings(candidate.SafetyRatings),
+		}
+		
+		if candidate.Content != nil {
+			c.Content.Role = candidate.Content.Role
+			for _, part := range candidate.Content.Parts {
+				if text, ok := part.(genai.Text); ok {
+					c.Content.Parts = append(c.Content.Parts, ContentText{Text: string(text)})
+				}
+			}
+		}
+		
+		response.Candidates = append(response.Candidates, c)
+		response.UsageMetadata.CandidatesTokenCount += int(candidate.TokenCount)
 	}
 	
-	fmt.Println(string(rb))
-	return nil
+	if resp.UsageMetadata != nil {
+		response.UsageMetadata.PromptTokenCount = int(resp.UsageMetadata.PromptTokenCount)
+		response.UsageMetadata.CandidatesTokenCount = int(resp.UsageMetadata.CandidatesTokenCount)
+		response.UsageMetadata.TotalTokenCount = int(resp.UsageMetadata.TotalTokenCount)
+		return response
+	}
+	
+	response.UsageMetadata.TotalTokenCount = response.UsageMetadata.CandidatesTokenCount
+	return response
+}
+
+func sdkSafetyRatings(ratings []*genai.SafetyRating) []CandidatesContentSafetyRatings {
+	var result []CandidatesContentSafetyRatings
+	for _, rating := range ratings {
+		result = append(result, CandidatesContentSafetyRatings{
+			Category:    sdkEnum(rating.Category.String(), ""),
+			Probability: sdkEnum(rating.Probability.String(), "HarmProbability"),
+			Blocked:     rating.Blocked,
+		})
+	}
+	return result
+}
+
+// SDK 枚举的名称转换为 REST 接口的取值，比如 FinishReasonMaxTokens -> MAX_TOKENS，HarmCategoryHarassment -> HARM_CATEGORY_HARASSMENT
+
+func sdkEnum(name, prefix string) string {
+	name = strings.TrimPrefix(name, prefix)
+	if name == "Unspecified" {
+		return ""
+	}
+	
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
 }
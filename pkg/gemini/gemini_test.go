@@ -0,0 +1,42 @@
+package gemini
+
+import (
+	"github.com/google/generative-ai-go/genai"
+	"testing"
+)
+
+func TestSdkResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *genai.GenerateContentResponse
+		want UsageMetadata
+	}{
+		{
+			name: "usageMetadata",
+			resp: &genai.GenerateContentResponse{
+				Candidates:    []*genai.Candidate{{FinishReason: genai.FinishReasonStop, TokenCount: 7, Content: &genai.Content{Role: "model", Parts: []genai.Part{genai.Text("你好")}}}},
+				UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 270, CandidatesTokenCount: 8, TotalTokenCount: 278},
+			},
+			want: UsageMetadata{PromptTokenCount: 270, CandidatesTokenCount: 8, TotalTokenCount: 278},
+		},
+		{
+			name: "没有 usageMetadata 时使用候选的 TokenCount",
+			resp: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonStop, TokenCount: 7, Content: &genai.Content{Role: "model", Parts: []genai.Part{genai.Text("你好")}}}},
+			},
+			want: UsageMetadata{CandidatesTokenCount: 7, TotalTokenCount: 7},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := sdkResponse(tt.resp)
+			if response.UsageMetadata != tt.want {
+				t.Errorf("UsageMetadata = %+v, want %+v", response.UsageMetadata, tt.want)
+			}
+			if response.Text() != "你好" || response.FinishReason() != FinishReasonStop {
+				t.Errorf("text = %q, finishReason = %q", response.Text(), response.FinishReason())
+			}
+		})
+	}
+}
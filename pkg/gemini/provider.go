@@ -0,0 +1,165 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+)
+
+const (
+	defaultChatModel = "gemini-1.5-flash"
+)
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client *RestClient
+}
+
+func NewProvider(client *RestClient) *Provider {
+	return &Provider{
+		client: client,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 2},
+		TopP:        chat.Range{Min: 0, Max: 1},
+		MaxStop:     5,
+		Seed:        true,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	model, contents := generateContents(req)
+	
+	resp, err := provider.client.GenerateContent(ctx, model, contents)
+	if err != nil {
+		return chat.Response{}, err
+	}
+	
+	response := chat.Response{
+		Model:        model,
+		Content:      resp.Text(),
+		FinishReason: resp.FinishReason(),
+		Usage:        resp.Usage(),
+	}
+	
+	if resp.ModelVersion != "" {
+		response.Model = resp.ModelVersion
+	}
+	
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback.BlockReason != "" {
+			response.FinishReason = resp.PromptFeedback.BlockReason
+			return response, fmt.Errorf("%w: %s", chat.ErrBlocked, resp.PromptFeedback.BlockReason)
+		}
+		return response, chat.ErrEmptyResponse
+	}
+	
+	return response, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	model, contents := generateContents(req)
+	response := chat.Response{Model: model}
+	var content strings.Builder
+	
+	err := provider.client.StreamGenerateContent(ctx, model, contents, func(resp Response) error {
+		// usageMetadata 为累计值，使用最后一条
+		if resp.UsageMetadata.TotalTokenCount > 0 {
+			response.Usage = resp.Usage()
+		}
+		
+		if resp.ModelVersion != "" {
+			response.Model = resp.ModelVersion
+		}
+		
+		if resp.PromptFeedback.BlockReason != "" {
+			response.FinishReason = resp.PromptFeedback.BlockReason
+			return fmt.Errorf("%w: %s", chat.ErrBlocked, resp.PromptFeedback.BlockReason)
+		}
+		
+		if len(resp.Candidates) == 0 {
+			return nil
+		}
+		
+		if resp.Candidates[0].FinishReason != "" {
+			response.FinishReason = resp.Candidates[0].FinishReason
+		}
+		
+		delta := candidateText(resp.Candidates[0])
+		if delta == "" {
+			return nil
+		}
+		content.WriteString(delta)
+		return onDelta(delta)
+	})
+	
+	response.Content = content.String()
+	return response, err
+}
+
+func generateContents(req chat.Request) (string, Contents) {
+	model := req.Model
+	if model == "" {
+		model = defaultChatModel
+	}
+	
+	var contents Contents
+	var system []string
+	
+	if req.System != "" {
+		system = append(system, req.System)
+	}
+	
+	for _, message := range req.Messages {
+		switch message.Role {
+		case chat.RoleSystem:
+			system = append(system, message.Content)
+		
+		case chat.RoleAssistant:
+			contents.Contents = append(contents.Contents, Content{
+				Role:  ChatRoleModel,
+				Parts: []interface{}{ContentText{Text: message.Content}},
+			})
+		
+		default:
+			contents.Contents = append(contents.Contents, Content{
+				Role:  ChatRoleUser,
+				Parts: []interface{}{ContentText{Text: message.Content}},
+			})
+		}
+	}
+	
+	if len(system) > 0 {
+		contents.SystemInstruction = &Content{
+			Parts: []interface{}{ContentText{Text: strings.Join(system, "\n")}},
+		}
+	}
+	
+	opts := req.Options
+	if opts.Temperature != nil || opts.TopP != nil || opts.MaxTokens > 0 || len(opts.Stop) > 0 || opts.Seed != nil {
+		contents.GenerationConfig = &GenerationConfig{
+			Temperature:     opts.Temperature,
+			TopP:            opts.TopP,
+			MaxOutputTokens: opts.MaxTokens,
+			StopSequences:   opts.Stop,
+			Seed:            opts.Seed,
+		}
+	}
+	
+	return model, contents
+}
+
+func candidateText(candidate Candidates) string {
+	var texts []string
+	for _, part := range candidate.Content.Parts {
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "")
+}
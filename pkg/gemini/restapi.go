@@ -1,16 +1,23 @@
 package gemini
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"github.com/startopsz/rule/pkg/http"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/qx66/pedant/pkg/sse"
+	"io"
+	nHttp "net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
-	Api = "https://generativelanguage.googleapis.com/v1beta/models/"
+	DefaultBaseUrl = "https://generativelanguage.googleapis.com/v1beta"
+	Api            = DefaultBaseUrl + "/models/"
 )
 
 const (
@@ -20,7 +27,17 @@ const (
 )
 
 type Contents struct {
-	Contents []Content `json:"contents,omitempty"`
+	Contents          []Content         `json:"contents,omitempty"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"` // 系统指令，gemini-pro 不支持，需要 gemini-1.5 及以上的模型
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"` // 范围 [0, 2]
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"` // 最多5个
+	Seed            *int64   `json:"seed,omitempty"`
 }
 
 type Content struct {
@@ -46,6 +63,68 @@ type ContentImg struct {
 type Response struct {
 	Candidates     []Candidates   `json:"candidates,omitempty"`
 	PromptFeedback PromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  UsageMetadata  `json:"usageMetadata,omitempty"` // 流式返回时每一条数据都是截至当前的累计值
+	ModelVersion   string         `json:"modelVersion,omitempty"`
+}
+
+// Text 第一个候选的内容，输入被拦截时没有候选
+
+func (response Response) Text() string {
+	if len(response.Candidates) == 0 {
+		return ""
+	}
+	return candidateText(response.Candidates[0])
+}
+
+// FinishReason 第一个候选停止生成的原因: STOP / MAX_TOKENS / SAFETY / RECITATION / OTHER ...
+
+func (response Response) FinishReason() string {
+	if len(response.Candidates) == 0 {
+		return ""
+	}
+	return response.Candidates[0].FinishReason
+}
+
+// BlockReason 输入被拦截 (promptFeedback.blockReason) 或输出因为安全原因被拦截 (finishReason) 的原因，没有被拦截时为空
+
+func (response Response) BlockReason() string {
+	if response.PromptFeedback.BlockReason != "" {
+		return response.PromptFeedback.BlockReason
+	}
+	
+	switch reason := response.FinishReason(); reason {
+	case FinishReasonSafety, FinishReasonRecitation, FinishReasonBlocklist, FinishReasonProhibitedContent, FinishReasonSpii:
+		return reason
+	}
+	return ""
+}
+
+func (response Response) Usage() chat.Usage {
+	return chat.Usage{
+		PromptTokens:     response.UsageMetadata.PromptTokenCount,
+		CompletionTokens: response.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:      response.UsageMetadata.TotalTokenCount,
+	}
+}
+
+const (
+	FinishReasonStop              = "STOP"
+	FinishReasonMaxTokens         = "MAX_TOKENS"
+	FinishReasonSafety            = "SAFETY"
+	FinishReasonRecitation        = "RECITATION"
+	FinishReasonOther             = "OTHER"
+	FinishReasonBlocklist         = "BLOCKLIST"
+	FinishReasonProhibitedContent = "PROHIBITED_CONTENT"
+	FinishReasonSpii              = "SPII" // 包含敏感的个人身份信息
+)
+
+// https://ai.google.dev/api/generate-content#UsageMetadata
+
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount,omitempty"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount,omitempty"`
+	TotalTokenCount         int `json:"totalTokenCount,omitempty"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
 }
 
 type Candidates struct {
@@ -63,15 +142,73 @@ type CandidatesContent struct {
 type CandidatesContentSafetyRatings struct {
 	Category    string `json:"category,omitempty"`
 	Probability string `json:"probability,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty"`
 }
 
 type PromptFeedback struct {
+	BlockReason   string                           `json:"blockReason,omitempty"` // SAFETY / OTHER / BLOCKLIST / PROHIBITED_CONTENT，不为空时没有候选
 	SafetyRatings []CandidatesContentSafetyRatings `json:"safetyRatings,omitempty"`
 }
 
+// RestClient baseUrl 可以指向内部网关，代理等通过 cli 设置
+
+type RestClient struct {
+	apiKey  string
+	baseUrl string
+	cli     *nHttp.Client
+}
+
+// NewRestClient baseUrl 为空时使用 DefaultBaseUrl，cli 为空时使用 http.DefaultClient
+
+func NewRestClient(apiKey, baseUrl string, cli *nHttp.Client) *RestClient {
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	
+	if cli == nil {
+		cli = nHttp.DefaultClient
+	}
+	
+	return &RestClient{
+		apiKey:  apiKey,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		cli:     cli,
+	}
+}
+
+// ApiKey 使用默认的 baseUrl 和 http.DefaultClient
+
 type ApiKey string
 
-func (apiKey ApiKey) Text(text string) (Response, error) {
+func (apiKey ApiKey) client() *RestClient {
+	return NewRestClient(string(apiKey), "", nil)
+}
+
+func (apiKey ApiKey) Text(ctx context.Context, text string) (Response, error) {
+	return apiKey.client().Text(ctx, text)
+}
+
+func (apiKey ApiKey) MultiModal(ctx context.Context, text string, imageBase64 ...string) (Response, error) {
+	return apiKey.client().MultiModal(ctx, text, imageBase64...)
+}
+
+func (apiKey ApiKey) TextAndImage(ctx context.Context, text string, imagePaths ...string) (Response, error) {
+	return apiKey.client().TextAndImage(ctx, text, imagePaths...)
+}
+
+func (apiKey ApiKey) Chat(ctx context.Context, history []Content, text string) (Response, error) {
+	return apiKey.client().Chat(ctx, history, text)
+}
+
+func (apiKey ApiKey) GenerateContent(ctx context.Context, model string, contents Contents) (Response, error) {
+	return apiKey.client().GenerateContent(ctx, model, contents)
+}
+
+func (apiKey ApiKey) StreamGenerateContent(ctx context.Context, model string, contents Contents, onResponse func(Response) error) error {
+	return apiKey.client().StreamGenerateContent(ctx, model, contents, onResponse)
+}
+
+func (client *RestClient) Text(ctx context.Context, text string) (Response, error) {
 	var response Response
 	
 	var parts []interface{}
@@ -93,36 +230,20 @@ func (apiKey ApiKey) Text(text string) (Response, error) {
 		return response, err
 	}
 	
-	realUrl := fmt.Sprintf("%s%s:generateContent?key=%s", Api, "gemini-pro", apiKey)
+	realUrl := fmt.Sprintf("%s/models/%s:generateContent?key=%s", client.baseUrl, "gemini-pro", client.apiKey)
 	
-	header := make(map[string]string)
-	header["Content-Type"] = "application/json"
-	
-	req := http.Req{
-		Method:  http.Post,
-		Url:     realUrl,
-		Body:    contentsByte,
-		Headers: header,
-		Timeout: 60,
-	}
-	
-	resp, err := req.Do()
+	respByte, err := client.post(ctx, realUrl, contentsByte)
 	if err != nil {
 		return response, err
 	}
 	
-	if resp.StatusCode != 200 {
-		return response, errors.New(fmt.Sprintf("status: %d, body: %s", resp.StatusCode, string(resp.Body)))
-	}
-	
-	//fmt.Println("resp.Body: ", string(resp.Body))
-	err = json.Unmarshal(resp.Body, &response)
+	err = json.Unmarshal(respByte, &response)
 	return response, err
 }
 
 // 经过测试，imagePaths 建议只传一张图片，多张图片测试效果不是很好
 
-func (apiKey ApiKey) MultiModal(text string, imageBase64 ...string) (Response, error) {
+func (client *RestClient) MultiModal(ctx context.Context, text string, imageBase64 ...string) (Response, error) {
 	var response Response
 	
 	//
@@ -155,33 +276,18 @@ func (apiKey ApiKey) MultiModal(text string, imageBase64 ...string) (Response, e
 		return response, err
 	}
 	
-	realUrl := fmt.Sprintf("%s%s:generateContent?key=%s", Api, "gemini-pro-vision", apiKey)
-	
-	header := make(map[string]string)
-	header["Content-Type"] = "application/json"
-	
-	req := http.Req{
-		Method:  http.Post,
-		Url:     realUrl,
-		Body:    contentsByte,
-		Headers: header,
-		Timeout: 60,
-	}
+	realUrl := fmt.Sprintf("%s/models/%s:generateContent?key=%s", client.baseUrl, "gemini-pro-vision", client.apiKey)
 	
-	resp, err := req.Do()
+	respByte, err := client.post(ctx, realUrl, contentsByte)
 	if err != nil {
 		return response, err
 	}
 	
-	if resp.StatusCode != 200 {
-		return response, errors.New(fmt.Sprintf("status: %d, body: %s", resp.StatusCode, string(resp.Body)))
-	}
-	
-	err = json.Unmarshal(resp.Body, &response)
+	err = json.Unmarshal(respByte, &response)
 	return response, err
 }
 
-func (apiKey ApiKey) TextAndImage(text string, imagePaths ...string) (Response, error) {
+func (client *RestClient) TextAndImage(ctx context.Context, text string, imagePaths ...string) (Response, error) {
 	var response Response
 	
 	//
@@ -220,35 +326,18 @@ func (apiKey ApiKey) TextAndImage(text string, imagePaths ...string) (Response,
 		return response, err
 	}
 	
-	realUrl := fmt.Sprintf("%s%s:generateContent?key=%s", Api, "gemini-pro-vision", apiKey)
+	realUrl := fmt.Sprintf("%s/models/%s:generateContent?key=%s", client.baseUrl, "gemini-pro-vision", client.apiKey)
 	
-	header := make(map[string]string)
-	header["Content-Type"] = "application/json"
-	
-	req := http.Req{
-		Method:  http.Post,
-		Url:     realUrl,
-		Body:    contentsByte,
-		Headers: header,
-		Timeout: 60,
-	}
-	
-	resp, err := req.Do()
+	respByte, err := client.post(ctx, realUrl, contentsByte)
 	if err != nil {
 		return response, err
 	}
 	
-	if resp.StatusCode != 200 {
-		return response, errors.New(fmt.Sprintf("status: %d, body: %s", resp.StatusCode, string(resp.Body)))
-	}
-	
-	err = json.Unmarshal(resp.Body, &response)
+	err = json.Unmarshal(respByte, &response)
 	return response, err
 }
 
-func (apiKey ApiKey) Chat(history []Content, text string) (Response, error) {
-	var response Response
-	
+func (client *RestClient) Chat(ctx context.Context, history []Content, text string) (Response, error) {
 	//
 	var parts []interface{}
 	parts = append(parts, ContentText{
@@ -264,33 +353,96 @@ func (apiKey ApiKey) Chat(history []Content, text string) (Response, error) {
 		Contents: history,
 	}
 	
+	return client.GenerateContent(ctx, "gemini-pro", contents)
+}
+
+func (client *RestClient) GenerateContent(ctx context.Context, model string, contents Contents) (Response, error) {
+	var response Response
+	
 	contentsByte, err := json.Marshal(contents)
 	if err != nil {
 		return response, err
 	}
 	
-	realUrl := fmt.Sprintf("%s%s:generateContent?key=%s", Api, "gemini-pro", apiKey)
+	realUrl := fmt.Sprintf("%s/models/%s:generateContent?key=%s", client.baseUrl, model, client.apiKey)
+	
+	respByte, err := client.post(ctx, realUrl, contentsByte)
+	if err != nil {
+		return response, err
+	}
+	
+	err = json.Unmarshal(respByte, &response)
+	return response, err
+}
+
+// StreamGenerateContent 使用 SSE 流式返回，每一条数据都是一个完整的 Response，Parts 中为增量内容
+
+func (client *RestClient) StreamGenerateContent(ctx context.Context, model string, contents Contents, onResponse func(Response) error) error {
+	contentsByte, err := json.Marshal(contents)
+	if err != nil {
+		return err
+	}
+	
+	realUrl := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s", client.baseUrl, model, client.apiKey)
+	
+	req, err := nHttp.NewRequestWithContext(ctx, nHttp.MethodPost, realUrl, bytes.NewBuffer(contentsByte))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != 200 {
+		respByte, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
+	}
+	
+	return sse.Read(resp.Body, func(data []byte) error {
+		var response Response
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return err
+		}
+		return onResponse(response)
+	})
+}
+
+// 非流式请求的超时时间，ctx 取消时同样会中止请求
+
+const requestTimeout = 60 * time.Second
+
+func (client *RestClient) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
 	
-	header := make(map[string]string)
-	header["Content-Type"] = "application/json"
+	req, err := nHttp.NewRequestWithContext(ctx, nHttp.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
 	
-	req := http.Req{
-		Method:  http.Post,
-		Url:     realUrl,
-		Body:    contentsByte,
-		Headers: header,
-		Timeout: 60,
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 	
-	resp, err := req.Do()
+	respByte, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return response, err
+		return nil, err
 	}
 	
 	if resp.StatusCode != 200 {
-		return response, errors.New(fmt.Sprintf("status: %d, body: %s", resp.StatusCode, string(resp.Body)))
+		return nil, chat.NewStatusError(resp.StatusCode, resp.Header, respByte)
 	}
 	
-	err = json.Unmarshal(resp.Body, &response)
-	return response, err
+	return respByte, nil
 }
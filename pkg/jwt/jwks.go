@@ -0,0 +1,218 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// https://www.rfc-editor.org/rfc/rfc7517
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // EC / OKP
+	X   string `json:"x,omitempty"`   // EC / OKP
+	Y   string `json:"y,omitempty"`   // EC
+}
+
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet JWKS 文件中的公钥
+// 遇到未知的 kid 时重新加载文件 (最多每分钟一次)，轮换密钥时只需要更新文件
+
+type KeySet struct {
+	path     string
+	mu       sync.Mutex
+	keys     []publicKey
+	loadedAt time.Time
+}
+
+var reloadInterval = time.Minute
+
+func LoadKeySet(path string) (*KeySet, error) {
+	keySet := &KeySet{path: path}
+	err := keySet.load()
+	if err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+func ParseKeySet(data []byte) (*KeySet, error) {
+	keys, err := parseKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{keys: keys}, nil
+}
+
+func (keySet *KeySet) load() error {
+	data, err := os.ReadFile(keySet.path)
+	if err != nil {
+		return err
+	}
+	
+	keys, err := parseKeys(data)
+	if err != nil {
+		return err
+	}
+	
+	keySet.keys = keys
+	keySet.loadedAt = time.Now()
+	return nil
+}
+
+// 有 kid 时按 kid 查找，否则返回所有可以用于 alg 的公钥
+
+func (keySet *KeySet) find(kid, alg string) ([]crypto.PublicKey, error) {
+	keySet.mu.Lock()
+	defer keySet.mu.Unlock()
+	
+	keys := keySet.match(kid, alg)
+	if len(keys) == 0 && kid != "" && keySet.path != "" && time.Since(keySet.loadedAt) > reloadInterval {
+		err := keySet.load()
+		if err != nil {
+			return nil, err
+		}
+		keys = keySet.match(kid, alg)
+	}
+	
+	if len(keys) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return keys, nil
+}
+
+func (keySet *KeySet) match(kid, alg string) []crypto.PublicKey {
+	var keys []crypto.PublicKey
+	for _, key := range keySet.keys {
+		if kid != "" && key.kid != kid {
+			continue
+		}
+		
+		if key.alg != "" && key.alg != alg {
+			continue
+		}
+		keys = append(keys, key.key)
+	}
+	return keys
+}
+
+func parseKeys(data []byte) ([]publicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	
+	err := json.Unmarshal(data, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	
+	var keys []publicKey
+	for _, jwk := range jwks.Keys {
+		// 只用于加密的公钥不能用来校验签名
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", jwk.Kid, err)
+		}
+		
+		keys = append(keys, publicKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no signing key")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid ec point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	
+	return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,246 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 只支持使用 JWKS 中的公钥校验签名的 JWT，不支持 HS256 等对称签名算法
+// https://www.rfc-editor.org/rfc/rfc7519
+
+var (
+	ErrMalformed      = errors.New("jwt: malformed token")
+	ErrUnsupportedAlg = errors.New("jwt: unsupported algorithm")
+	ErrKeyNotFound    = errors.New("jwt: key not found")
+	ErrSignature      = errors.New("jwt: invalid signature")
+	ErrExpired        = errors.New("jwt: token is expired")
+	ErrMissingExp     = errors.New("jwt: token has no exp")
+	ErrNotValidYet    = errors.New("jwt: token is not valid yet")
+	ErrIssuer         = errors.New("jwt: invalid issuer")
+	ErrAudience       = errors.New("jwt: invalid audience")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Claims JWT 的 payload
+
+type Claims map[string]any
+
+func (claims Claims) String(name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// Audience aud 可以是字符串或字符串数组
+
+func (claims Claims) Audience() []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		var result []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func (claims Claims) time(name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// Verifier 校验签名、exp / nbf 以及配置了的 iss / aud
+
+type Verifier struct {
+	Issuer   string // 为空时不校验
+	Audience string // 为空时不校验
+	Keys     *KeySet
+	Leeway   time.Duration // 允许的时钟误差
+}
+
+func (verifier *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	
+	var h header
+	err := decodeSegment(parts[0], &h)
+	if err != nil {
+		return nil, err
+	}
+	
+	// alg 为 none 的 token 没有签名
+	if h.Alg == "" || h.Alg == "none" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+	
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	
+	keys, err := verifier.Keys.find(h.Kid, h.Alg)
+	if err != nil {
+		return nil, err
+	}
+	
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		err = verify(h.Alg, key, signed, signature)
+		if err == nil {
+			verified = true
+			break
+		}
+		if errors.Is(err, ErrUnsupportedAlg) {
+			return nil, err
+		}
+	}
+	
+	if !verified {
+		return nil, ErrSignature
+	}
+	
+	var claims Claims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, err
+	}
+	
+	return claims, verifier.validate(claims)
+}
+
+func (verifier *Verifier) validate(claims Claims) error {
+	now := time.Now()
+	
+	// 没有 exp 的 token 永久有效，泄露后无法失效
+	exp, ok := claims.time("exp")
+	if !ok {
+		return ErrMissingExp
+	}
+	
+	if now.After(exp.Add(verifier.Leeway)) {
+		return ErrExpired
+	}
+	
+	nbf, ok := claims.time("nbf")
+	if ok && now.Add(verifier.Leeway).Before(nbf) {
+		return ErrNotValidYet
+	}
+	
+	if verifier.Issuer != "" && claims.String("iss") != verifier.Issuer {
+		return ErrIssuer
+	}
+	
+	if verifier.Audience != "" {
+		for _, aud := range claims.Audience() {
+			if aud == verifier.Audience {
+				return nil
+			}
+		}
+		return ErrAudience
+	}
+	
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func verify(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		
+		hash := hashOf(alg)
+		h := hash.New()
+		h.Write(signed)
+		
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(publicKey, hash, h.Sum(nil), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(publicKey, hash, h.Sum(nil), signature)
+	
+	case "ES256", "ES384", "ES512":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		
+		// 签名为定长的 r || s
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrSignature
+		}
+		
+		h := hashOf(alg).New()
+		h.Write(signed)
+		
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, h.Sum(nil), r, s) {
+			return ErrSignature
+		}
+		return nil
+	
+	case "EdDSA":
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		
+		if !ed25519.Verify(publicKey, signed, signature) {
+			return ErrSignature
+		}
+		return nil
+	}
+	
+	return fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+}
+
+func hashOf(alg string) crypto.Hash {
+	switch alg[2:] {
+	case "384":
+		return crypto.SHA384
+	case "512":
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
@@ -0,0 +1,365 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	kid     string
+	alg     string
+	private crypto.Signer
+	jwk     jsonWebKey
+}
+
+func newRsaKey(t *testing.T, kid, alg string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: alg, private: key, jwk: jsonWebKey{
+		Kty: "RSA", Kid: kid, Alg: alg, Use: "sig",
+		N: encodeBigInt(key.N), E: encodeBigInt(big.NewInt(int64(key.E))),
+	}}
+}
+
+func newEcKey(t *testing.T, kid, alg string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: alg, private: key, jwk: jsonWebKey{
+		Kty: "EC", Kid: kid, Alg: alg, Crv: "P-256",
+		X: encodeFixed(key.X, 32), Y: encodeFixed(key.Y, 32),
+	}}
+}
+
+func newEd25519Key(t *testing.T, kid string) testKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: "EdDSA", private: private, jwk: jsonWebKey{
+		Kty: "OKP", Kid: kid, Alg: "EdDSA", Crv: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(public),
+	}}
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func encodeFixed(i *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+}
+
+func jwks(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.jwk)
+	}
+	
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// 使用 key 签名，alg 和 kid 可以与 key 不一致，用于构造错误的 token
+
+func sign(t *testing.T, key testKey, alg, kid string, claims Claims) string {
+	t.Helper()
+	signed := encodeSegment(t, header{Alg: alg, Kid: kid, Typ: "JWT"}) + "." + encodeSegment(t, claims)
+	
+	var signature []byte
+	var err error
+	switch private := key.private.(type) {
+	case *rsa.PrivateKey:
+		hash := hashOf(key.alg)
+		h := hash.New()
+		h.Write([]byte(signed))
+		if strings.HasPrefix(key.alg, "PS") {
+			signature, err = rsa.SignPSS(rand.Reader, private, hash, h.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, private, hash, h.Sum(nil))
+		}
+	case *ecdsa.PrivateKey:
+		h := hashOf(key.alg).New()
+		h.Write([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, private, h.Sum(nil))
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(private, []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func claims(overrides Claims) Claims {
+	now := time.Now()
+	c := Claims{
+		"sub": "user",
+		"iss": "https://issuer.example.com",
+		"aud": "pedant",
+		"iat": float64(now.Unix()),
+		"exp": float64(now.Add(time.Hour).Unix()),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(c, name)
+			continue
+		}
+		c[name] = value
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	rs256 := newRsaKey(t, "rs256", "RS256")
+	ps256 := newRsaKey(t, "ps256", "PS256")
+	es256 := newEcKey(t, "es256", "ES256")
+	esNoAlg := newEcKey(t, "es-no-alg", "")
+	esNoAlg.alg = "ES256"
+	eddsa := newEd25519Key(t, "eddsa")
+	
+	keys, err := ParseKeySet(jwks(t, rs256, ps256, es256, esNoAlg, eddsa))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	verifier := &Verifier{
+		Issuer:   "https://issuer.example.com",
+		Audience: "pedant",
+		Keys:     keys,
+		Leeway:   time.Minute,
+	}
+	
+	now := time.Now()
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"RS256", sign(t, rs256, "RS256", "rs256", claims(nil)), nil},
+		{"PS256", sign(t, ps256, "PS256", "ps256", claims(nil)), nil},
+		{"ES256", sign(t, es256, "ES256", "es256", claims(nil)), nil},
+		{"EdDSA", sign(t, eddsa, "EdDSA", "eddsa", claims(nil)), nil},
+		{"无 kid 时尝试所有公钥", sign(t, es256, "ES256", "", claims(nil)), nil},
+		{"aud 数组", sign(t, rs256, "RS256", "rs256", claims(Claims{"aud": []any{"other", "pedant"}})), nil},
+		{"在误差范围内过期", sign(t, rs256, "RS256", "rs256", claims(Claims{"exp": float64(now.Add(-30 * time.Second).Unix())})), nil},
+		
+		{"alg none", encodeSegment(t, header{Alg: "none"}) + "." + encodeSegment(t, claims(nil)) + ".", ErrUnsupportedAlg},
+		{"alg 为空", encodeSegment(t, header{Kid: "rs256"}) + "." + encodeSegment(t, claims(nil)) + ".", ErrUnsupportedAlg},
+		{"alg 与公钥的 alg 不一致", sign(t, rs256, "ES256", "rs256", claims(nil)), ErrKeyNotFound},
+		{"alg 与公钥类型不一致", sign(t, rs256, "RS256", "es-no-alg", claims(nil)), ErrSignature},
+		{"HS256", sign(t, es256, "HS256", "es-no-alg", claims(nil)), ErrUnsupportedAlg},
+		{"未知 kid", sign(t, rs256, "RS256", "unknown", claims(nil)), ErrKeyNotFound},
+		
+		{"过期", sign(t, rs256, "RS256", "rs256", claims(Claims{"exp": float64(now.Add(-time.Hour).Unix())})), ErrExpired},
+		{"没有 exp", sign(t, rs256, "RS256", "rs256", claims(Claims{"exp": nil})), ErrMissingExp},
+		{"exp 不是数字", sign(t, rs256, "RS256", "rs256", claims(Claims{"exp": "tomorrow"})), ErrMissingExp},
+		{"nbf 在未来", sign(t, rs256, "RS256", "rs256", claims(Claims{"nbf": float64(now.Add(time.Hour).Unix())})), ErrNotValidYet},
+		{"iss 错误", sign(t, rs256, "RS256", "rs256", claims(Claims{"iss": "https://evil.example.com"})), ErrIssuer},
+		{"没有 iss", sign(t, rs256, "RS256", "rs256", claims(Claims{"iss": nil})), ErrIssuer},
+		{"aud 错误", sign(t, rs256, "RS256", "rs256", claims(Claims{"aud": "other"})), ErrAudience},
+		{"aud 数组中没有", sign(t, rs256, "RS256", "rs256", claims(Claims{"aud": []any{"a", "b"}})), ErrAudience},
+		
+		{"签名被篡改", tamperSignature(sign(t, rs256, "RS256", "rs256", claims(nil))), ErrSignature},
+		{"ES256 签名被篡改", tamperSignature(sign(t, es256, "ES256", "es256", claims(nil))), ErrSignature},
+		{"EdDSA 签名被篡改", tamperSignature(sign(t, eddsa, "EdDSA", "eddsa", claims(nil))), ErrSignature},
+		{"payload 被篡改", tamperPayload(t, sign(t, rs256, "RS256", "rs256", claims(nil))), ErrSignature},
+		{"使用其他公钥的 kid", sign(t, ps256, "PS256", "rs256", claims(nil)), ErrKeyNotFound},
+		{"签名长度错误", sign(t, es256, "ES256", "es256", claims(nil)) + "AA", ErrSignature},
+		
+		{"不是三段", "a.b", ErrMalformed},
+		{"header 不是 base64url", "!!!." + encodeSegment(t, claims(nil)) + ".sig", ErrMalformed},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := verifier.Verify(tt.token)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if c.String("sub") != "user" {
+					t.Fatalf("sub = %q", c.String("sub"))
+				}
+				return
+			}
+			
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutIssuerAndAudience(t *testing.T) {
+	key := newEd25519Key(t, "eddsa")
+	keys, err := ParseKeySet(jwks(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	verifier := &Verifier{Keys: keys}
+	_, err = verifier.Verify(sign(t, key, "EdDSA", "eddsa", claims(Claims{"iss": nil, "aud": nil})))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	interval := reloadInterval
+	defer func() { reloadInterval = interval }()
+	
+	oldKey := newEd25519Key(t, "old")
+	newKey := newEd25519Key(t, "new")
+	
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err := os.WriteFile(path, jwks(t, oldKey), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	keys, err := LoadKeySet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := &Verifier{Keys: keys}
+	token := sign(t, newKey, "EdDSA", "new", claims(nil))
+	
+	// 轮换密钥
+	err = os.WriteFile(path, jwks(t, newKey), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// 距离上次加载不到 reloadInterval，不重新加载
+	reloadInterval = time.Hour
+	_, err = verifier.Verify(token)
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Verify() before reload error = %v, want %v", err, ErrKeyNotFound)
+	}
+	
+	reloadInterval = 0
+	_, err = verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() after reload error = %v", err)
+	}
+	
+	// 重新加载后旧的公钥不再有效
+	_, err = verifier.Verify(sign(t, oldKey, "EdDSA", "old", claims(nil)))
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Verify() with old key error = %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestKeySetReloadFailure(t *testing.T) {
+	interval := reloadInterval
+	defer func() { reloadInterval = interval }()
+	reloadInterval = 0
+	
+	key := newEd25519Key(t, "key")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err := os.WriteFile(path, jwks(t, key), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	keys, err := LoadKeySet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	err = os.WriteFile(path, []byte("not json"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// 未知 kid 触发重新加载失败时返回错误，已知 kid 不重新加载
+	verifier := &Verifier{Keys: keys}
+	_, err = verifier.Verify(sign(t, key, "EdDSA", "unknown", claims(nil)))
+	if err == nil {
+		t.Fatal("Verify() with unknown kid and broken jwks error = nil")
+	}
+	
+	_, err = verifier.Verify(sign(t, key, "EdDSA", "key", claims(nil)))
+	if err != nil {
+		t.Fatalf("Verify() with known kid error = %v", err)
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"没有公钥", `{"keys":[]}`, true},
+		{"只有加密公钥", `{"keys":[{"kty":"OKP","crv":"Ed25519","use":"enc","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`, true},
+		{"不支持的曲线", `{"keys":[{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}]}`, true},
+		{"不在曲线上的点", `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`, true},
+		{"不支持的类型", `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, true},
+		{"不是 json", `keys`, true},
+		{"Ed25519", `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKeySet([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func tamperSignature(token string) string {
+	parts := strings.Split(token, ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	signature[len(signature)/2] ^= 0xff
+	parts[2] = base64.RawURLEncoding.EncodeToString(signature)
+	return strings.Join(parts, ".")
+}
+
+func tamperPayload(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	parts[1] = encodeSegment(t, claims(Claims{"sub": "admin"}))
+	return strings.Join(parts, ".")
+}
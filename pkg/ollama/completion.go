@@ -1,11 +1,13 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/qx66/pedant/pkg/chat"
 	"io"
 	"net/http"
 )
@@ -18,11 +20,19 @@ const (
 )
 
 type Client struct {
-	BasicUrl string `json:"basicUrl,omitempty"` // 基础Url，比如: http://127.0.0.1:11434
+	BasicUrl string       `json:"basicUrl,omitempty"` // 基础Url，比如: http://127.0.0.1:11434
+	cli      *http.Client // 为空时使用 http.DefaultClient
 }
 
-func NewClient(basicUrl string) *Client {
-	return &Client{BasicUrl: basicUrl}
+func NewClient(basicUrl string, cli *http.Client) *Client {
+	return &Client{BasicUrl: basicUrl, cli: cli}
+}
+
+func (client *Client) httpClient() *http.Client {
+	if client.cli == nil {
+		return http.DefaultClient
+	}
+	return client.cli
 }
 
 // GenerateCompletion
@@ -86,7 +96,13 @@ func (client *Client) GenerateCompletion(ctx context.Context, model string, prom
 		return err
 	}
 	
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqByte))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqByte))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.httpClient().Do(httpReq)
 	if err != nil {
 		return err
 	}
@@ -117,8 +133,8 @@ func (client *Client) GenerateCompletionReturnStruct(ctx context.Context, reqPar
 		return "", err
 	}
 	
-	cli := &http.Client{}
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(reqByte))
+	cli := client.httpClient()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqByte))
 	
 	// 如果需要中文响应，需要设置header language 为 zh-CN -- 测试下来，效果不是很好
 	// 问题中如果没有英文，可以生成中文内容。
@@ -168,10 +184,20 @@ type ChatCompletionReq struct {
 	Format   string                  `json:"format,omitempty"` // the format to return a response in. Format can be json or a JSON schema.
 	Stream   bool                    `json:"stream"`           // if false the response will be returned as a single response object, rather than a stream of objects
 	Tools    []byte                  `json:"tools,omitempty"`
-	//options // additional model parameters listed in the documentation for the Modelfile such as temperature
+	Options  *ChatCompletionOptions  `json:"options,omitempty"` // additional model parameters listed in the documentation for the Modelfile such as temperature
 	//keep_alive // controls how long the model will stay loaded into memory following the request (default: 5m)
 }
 
+// Modelfile 中的参数，只列出常用的采样参数
+
+type ChatCompletionOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"` // 最大生成 token 数
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+}
+
 type ChatCompletionMessage struct {
 	Role      string `json:"role,omitempty"`       // the role of the message, either system, user, assistant, or tool
 	Content   string `json:"content,omitempty"`    // the content of the message
@@ -211,13 +237,15 @@ func (client *Client) ChatCompletion(ctx context.Context, req *ChatCompletionReq
 	
 	url := fmt.Sprintf("%s%s", client.BasicUrl, generateChatCompletionUri)
 	
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqByte))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqByte))
 	if err != nil {
 		return result, err
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
 	
-	if resp.StatusCode != 200 {
-		return result, errors.New(fmt.Sprintf("httpCodeError, %d", resp.StatusCode))
+	resp, err := client.httpClient().Do(httpReq)
+	if err != nil {
+		return result, err
 	}
 	
 	defer resp.Body.Close()
@@ -226,6 +254,10 @@ func (client *Client) ChatCompletion(ctx context.Context, req *ChatCompletionReq
 		return result, err
 	}
 	
+	if resp.StatusCode != 200 {
+		return result, chat.NewStatusError(resp.StatusCode, resp.Header, respBodyByte)
+	}
+	
 	err = json.Unmarshal(respBodyByte, &result)
 	if err != nil {
 		//fmt.Println("respBody: ", string(respBodyByte))
@@ -239,5 +271,63 @@ func (client *Client) ChatCompletion(ctx context.Context, req *ChatCompletionReq
 	return result, nil
 }
 
+// ChatCompletionStream 流式返回，每一行都是一个 json 对象 (NDJSON)，done 为 true 的最后一行包含统计信息
+
+func (client *Client) ChatCompletionStream(ctx context.Context, req *ChatCompletionReq, onResponse func(ChatCompletionResponse) error) error {
+	req.Stream = true
+	reqByte, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	
+	url := fmt.Sprintf("%s%s", client.BasicUrl, generateChatCompletionUri)
+	
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqByte))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.httpClient().Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != 200 {
+		respBodyByte, _ := io.ReadAll(resp.Body)
+		return chat.NewStatusError(resp.StatusCode, resp.Header, respBodyByte)
+	}
+	
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		
+		if len(bytes.TrimSpace(line)) > 0 {
+			var result ChatCompletionResponse
+			e := json.Unmarshal(line, &result)
+			if e != nil {
+				return e
+			}
+			
+			e = onResponse(result)
+			if e != nil {
+				return e
+			}
+			
+			if result.Done {
+				return nil
+			}
+		}
+		
+		if err == io.EOF {
+			return errors.New("stream closed before done")
+		}
+	}
+}
+
 // 解析格式
 // AI 可以根据你的内容，解析成Json格式返回
@@ -0,0 +1,114 @@
+package ollama
+
+import (
+	"context"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+)
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client *Client
+	model  string // 默认模型，比如: qwen2.5:7b
+}
+
+func NewProvider(client *Client, model string) *Provider {
+	return &Provider{
+		client: client,
+		model:  model,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 2},
+		TopP:        chat.Range{Min: 0, Max: 1},
+		MaxStop:     -1,
+		Seed:        true,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	resp, err := provider.client.ChatCompletion(ctx, provider.generateChatCompletionReq(req))
+	if err != nil {
+		return chat.Response{}, err
+	}
+	
+	return chat.Response{
+		Model:        resp.Model,
+		Content:      resp.Message.Content,
+		FinishReason: resp.DoneReason,
+		Usage: chat.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	body := provider.generateChatCompletionReq(req)
+	response := chat.Response{Model: body.Model}
+	var content strings.Builder
+	
+	err := provider.client.ChatCompletionStream(ctx, body, func(resp ChatCompletionResponse) error {
+		if resp.Done {
+			response.FinishReason = resp.DoneReason
+			response.Usage = chat.Usage{
+				PromptTokens:     resp.PromptEvalCount,
+				CompletionTokens: resp.EvalCount,
+				TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+			}
+		}
+		
+		delta := resp.Message.Content
+		if delta == "" {
+			return nil
+		}
+		content.WriteString(delta)
+		return onDelta(delta)
+	})
+	
+	response.Content = content.String()
+	return response, err
+}
+
+func (provider *Provider) generateChatCompletionReq(req chat.Request) *ChatCompletionReq {
+	body := &ChatCompletionReq{
+		Model: req.Model,
+	}
+	
+	if body.Model == "" {
+		body.Model = provider.model
+	}
+	
+	if req.System != "" {
+		body.Messages = append(body.Messages, ChatCompletionMessage{
+			Role:    chat.RoleSystem,
+			Content: req.System,
+		})
+	}
+	
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, ChatCompletionMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	
+	opts := req.Options
+	if opts.Temperature != nil || opts.TopP != nil || opts.MaxTokens > 0 || len(opts.Stop) > 0 || opts.Seed != nil {
+		body.Options = &ChatCompletionOptions{
+			Temperature: opts.Temperature,
+			TopP:        opts.TopP,
+			NumPredict:  opts.MaxTokens,
+			Stop:        opts.Stop,
+			Seed:        opts.Seed,
+		}
+	}
+	
+	return body
+}
@@ -2,11 +2,15 @@ package openai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/qx66/pedant/pkg/sse"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 /*
@@ -26,8 +30,19 @@ type TextDavinci003 struct {
 */
 
 type GptTurbo0301 struct {
-	Model    string                `json:"model,omitempty"`
-	Messages []GptTurbo0301Message `json:"messages,omitempty"`
+	Model         string                `json:"model,omitempty"`
+	Messages      []GptTurbo0301Message `json:"messages,omitempty"`
+	Stream        bool                  `json:"stream,omitempty"`
+	StreamOptions *ChatStreamOptions    `json:"stream_options,omitempty"` // 只有 stream 为 true 时才可以设置
+	Temperature   *float64              `json:"temperature,omitempty"`    // 默认1，范围 [0, 2]
+	TopP          *float64              `json:"top_p,omitempty"`          // 默认1，范围 [0, 1]
+	MaxTokens     int                   `json:"max_tokens,omitempty"`
+	Stop          []string              `json:"stop,omitempty"` // 最多4个
+	Seed          *int64                `json:"seed,omitempty"`
+}
+
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty"` // 最后一条数据返回整个请求的 usage
 }
 
 type GptTurbo0301Message struct {
@@ -36,12 +51,39 @@ type GptTurbo0301Message struct {
 }
 
 const (
-	chatApi                  = "https://api.openai.com/v1/chat/completions"
+	DefaultBaseUrl           = "https://api.openai.com/v1"
+	chatApi                  = "/chat/completions"
 	chatApiContentType       = "application/json"
-	completionApi            = "https://api.openai.com/v1/completions"
+	completionApi            = "/completions"
 	completionApiContentType = "application/json"
 )
 
+// Client baseUrl 可以指向兼容 openai 接口的网关，代理等通过 cli 设置
+
+type Client struct {
+	apiKey  string
+	baseUrl string
+	cli     *http.Client
+}
+
+// NewClient baseUrl 为空时使用 DefaultBaseUrl，cli 为空时使用 http.DefaultClient
+
+func NewClient(apiKey, baseUrl string, cli *http.Client) *Client {
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	
+	return &Client{
+		apiKey:  apiKey,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		cli:     cli,
+	}
+}
+
 const (
 	ChatRoleSystem    = "system"
 	ChatRoleUser      = "user"
@@ -78,6 +120,23 @@ type ChatModuleResponseChoicesMessage struct {
 	Content string `json:"content,omitempty"`
 }
 
+// 流式响应，每一条数据只包含增量的 delta
+
+type ChatModuleStreamResponse struct {
+	Id      string                            `json:"id,omitempty"`
+	Object  string                            `json:"object,omitempty"`
+	Created int64                             `json:"created,omitempty"`
+	Model   string                            `json:"model,omitempty"`
+	Usage   *ChatModuleResponseUsage          `json:"usage,omitempty"` // 只在最后一条数据中返回
+	Choices []ChatModuleStreamResponseChoices `json:"choices,omitempty"`
+}
+
+type ChatModuleStreamResponseChoices struct {
+	Delta        ChatModuleResponseChoicesMessage `json:"delta,omitempty"`
+	FinishReason string                           `json:"finish_reason,omitempty"`
+	Index        int                              `json:"index,omitempty"`
+}
+
 type CompletionModuleResponse struct {
 	Id      string                            `json:"id,omitempty"`
 	Object  string                            `json:"object,omitempty"`
@@ -100,22 +159,25 @@ type CompletionModuleResponseChoices struct {
 	Index        int    `json:"index,omitempty"`
 }
 
-func SendCompletion(body TextDavinci003, apiKey string) (CompletionModuleResponse, error) {
+func SendCompletion(ctx context.Context, body TextDavinci003, apiKey string) (CompletionModuleResponse, error) {
+	return NewClient(apiKey, "", nil).SendCompletion(ctx, body)
+}
+
+func (client *Client) SendCompletion(ctx context.Context, body TextDavinci003) (CompletionModuleResponse, error) {
 	var completionModuleResponse CompletionModuleResponse
 	b, err := json.Marshal(body)
 	if err != nil {
 		return completionModuleResponse, err
 	}
-	req, err := http.NewRequest("POST", completionApi, bytes.NewBuffer(b))
+	req, err := http.NewRequestWithContext(ctx, "POST", client.baseUrl+completionApi, bytes.NewBuffer(b))
 	if err != nil {
 		return completionModuleResponse, err
 	}
 	
 	req.Header.Set("Content-Type", completionApiContentType)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.apiKey))
 	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := client.cli.Do(req)
 	if err != nil {
 		return completionModuleResponse, err
 	}
@@ -137,23 +199,26 @@ func SendCompletion(body TextDavinci003, apiKey string) (CompletionModuleRespons
 	return completionModuleResponse, nil
 }
 
-func SendChat(body GptTurbo0301, apiKey string) (ChatModuleResponse, error) {
+func SendChat(ctx context.Context, body GptTurbo0301, apiKey string) (ChatModuleResponse, error) {
+	return NewClient(apiKey, "", nil).SendChat(ctx, body)
+}
+
+func (client *Client) SendChat(ctx context.Context, body GptTurbo0301) (ChatModuleResponse, error) {
 	var chatModuleResponse ChatModuleResponse
 	b, err := json.Marshal(body)
 	if err != nil {
 		return chatModuleResponse, err
 	}
 	
-	req, err := http.NewRequest("POST", chatApi, bytes.NewBuffer(b))
+	req, err := http.NewRequestWithContext(ctx, "POST", client.baseUrl+chatApi, bytes.NewBuffer(b))
 	if err != nil {
 		return chatModuleResponse, err
 	}
 	
 	req.Header.Set("Content-Type", chatApiContentType)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.apiKey))
 	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := client.cli.Do(req)
 	if err != nil {
 		return chatModuleResponse, err
 	}
@@ -165,7 +230,7 @@ func SendChat(body GptTurbo0301, apiKey string) (ChatModuleResponse, error) {
 	}
 	
 	if resp.StatusCode != http.StatusOK {
-		return chatModuleResponse, errors.New(string(respBodyByte))
+		return chatModuleResponse, chat.NewStatusError(resp.StatusCode, resp.Header, respBodyByte)
 	}
 	
 	err = json.Unmarshal(respBodyByte, &chatModuleResponse)
@@ -174,3 +239,51 @@ func SendChat(body GptTurbo0301, apiKey string) (ChatModuleResponse, error) {
 	}
 	return chatModuleResponse, nil
 }
+
+// SendChatStream 以流式请求对话接口，每收到一条增量数据调用一次 onResponse
+
+func SendChatStream(ctx context.Context, body GptTurbo0301, apiKey string, onResponse func(ChatModuleStreamResponse) error) error {
+	return NewClient(apiKey, "", nil).SendChatStream(ctx, body, onResponse)
+}
+
+func (client *Client) SendChatStream(ctx context.Context, body GptTurbo0301, onResponse func(ChatModuleStreamResponse) error) error {
+	body.Stream = true
+	body.StreamOptions = &ChatStreamOptions{IncludeUsage: true}
+	
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, "POST", client.baseUrl+chatApi, bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	
+	req.Header.Set("Content-Type", chatApiContentType)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.apiKey))
+	
+	resp, err := client.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		respBodyByte, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return chat.NewStatusError(resp.StatusCode, resp.Header, respBodyByte)
+	}
+	
+	return sse.Read(resp.Body, func(data []byte) error {
+		var chatModuleStreamResponse ChatModuleStreamResponse
+		err := json.Unmarshal(data, &chatModuleStreamResponse)
+		if err != nil {
+			return err
+		}
+		return onResponse(chatModuleStreamResponse)
+	})
+}
@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"context"
+	"github.com/qx66/pedant/pkg/chat"
+	"strings"
+)
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client *Client
+}
+
+func NewProvider(client *Client) *Provider {
+	return &Provider{
+		client: client,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 2},
+		TopP:        chat.Range{Min: 0, Max: 1},
+		MaxStop:     4,
+		Seed:        true,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	body := generateChatBody(req)
+	
+	resp, err := provider.client.SendChat(ctx, body)
+	if err != nil {
+		return chat.Response{}, err
+	}
+	
+	if len(resp.Choices) == 0 {
+		return chat.Response{}, chat.ErrEmptyResponse
+	}
+	
+	return chat.Response{
+		Model:        resp.Model,
+		Content:      resp.Choices[0].Message.Content,
+		FinishReason: resp.Choices[0].FinishReason,
+		Usage: chat.Usage{
+			PromptTokens:     int(resp.Usage.PromptTokens),
+			CompletionTokens: int(resp.Usage.CompletionTokens),
+			TotalTokens:      int(resp.Usage.TotalTokens),
+		},
+	}, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	body := generateChatBody(req)
+	response := chat.Response{Model: body.Model}
+	var content strings.Builder
+	
+	err := provider.client.SendChatStream(ctx, body, func(resp ChatModuleStreamResponse) error {
+		if resp.Usage != nil {
+			response.Usage = chat.Usage{
+				PromptTokens:     int(resp.Usage.PromptTokens),
+				CompletionTokens: int(resp.Usage.CompletionTokens),
+				TotalTokens:      int(resp.Usage.TotalTokens),
+			}
+		}
+		
+		if len(resp.Choices) == 0 {
+			return nil
+		}
+		
+		if resp.Choices[0].FinishReason != "" {
+			response.FinishReason = resp.Choices[0].FinishReason
+		}
+		
+		delta := resp.Choices[0].Delta.Content
+		if delta == "" {
+			return nil
+		}
+		content.WriteString(delta)
+		return onDelta(delta)
+	})
+	
+	response.Content = content.String()
+	return response, err
+}
+
+func generateChatBody(req chat.Request) GptTurbo0301 {
+	body := GptTurbo0301{
+		Model: req.Model,
+	}
+	
+	if body.Model == "" {
+		body.Model = ChatModuleGpt35Turbo
+	}
+	
+	if req.System != "" {
+		body.Messages = append(body.Messages, GptTurbo0301Message{
+			Role:    ChatRoleSystem,
+			Content: req.System,
+		})
+	}
+	
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, GptTurbo0301Message{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	
+	body.Temperature = req.Options.Temperature
+	body.TopP = req.Options.TopP
+	body.MaxTokens = req.Options.MaxTokens
+	body.Stop = req.Options.Stop
+	body.Seed = req.Options.Seed
+	return body
+}
@@ -0,0 +1,46 @@
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// text/event-stream 解析
+// 各个大模型厂商的流式接口 (openai / gemini / ernieBot / dashscope / deepseek) 均使用 SSE 格式返回增量数据
+
+const (
+	doneData = "[DONE]"
+)
+
+// Read 逐行读取 SSE 数据，每读取到一条 data 调用一次 onData，读取到 [DONE] 或 EOF 时返回 nil
+// onData 返回 error 时停止读取并返回该 error
+
+func Read(r io.Reader, onData func(data []byte) error) error {
+	reader := bufio.NewReader(r)
+	
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "data:") {
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == doneData {
+				return nil
+			}
+			
+			if data != "" {
+				if e := onData([]byte(data)); e != nil {
+					return e
+				}
+			}
+		}
+		
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime"
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
 	"github.com/volcengine/volcengine-go-sdk/volcengine"
+	"net/http"
 	"time"
 )
 
@@ -16,8 +17,23 @@ type Client struct {
 	cli *arkruntime.Client
 }
 
-func NewClient(apiKey string, timeout int) *Client {
-	cli := arkruntime.NewClientWithApiKey(apiKey, arkruntime.WithTimeout(time.Duration(timeout)*time.Second))
+// NewClient baseUrl 为空时使用 SDK 默认的地址，httpClient 为空时使用 SDK 默认的 http.Client
+
+func NewClient(apiKey, baseUrl string, httpClient *http.Client, timeout int) *Client {
+	opts := []arkruntime.ConfigOption{arkruntime.WithTimeout(time.Duration(timeout) * time.Second)}
+	
+	if baseUrl != "" {
+		opts = append(opts, arkruntime.WithBaseUrl(baseUrl))
+	}
+	
+	// 复制一份再设置超时，避免修改调用方传入的 http.Client
+	if httpClient != nil {
+		c := *httpClient
+		c.Timeout = time.Duration(timeout) * time.Second
+		opts = append(opts, arkruntime.WithHTTPClient(&c))
+	}
+	
+	cli := arkruntime.NewClientWithApiKey(apiKey, opts...)
 	return &Client{
 		cli: cli,
 	}
@@ -0,0 +1,167 @@
+package volcengine
+
+import (
+	"context"
+	"errors"
+	"github.com/qx66/pedant/pkg/chat"
+	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+	"github.com/volcengine/volcengine-go-sdk/volcengine"
+	"io"
+	"strings"
+)
+
+// Provider 实现 chat.Provider 接口
+
+type Provider struct {
+	client   *Client
+	endpoint string // 默认推理接入点ID，比如: ep-20240101000000-xxxxx
+}
+
+func NewProvider(client *Client, endpoint string) *Provider {
+	return &Provider{
+		client:   client,
+		endpoint: endpoint,
+	}
+}
+
+// Limits 参数范围参考厂商文档
+// SDK 中的字段没有使用指针，为 0 时会被忽略而使用默认值，因此不允许 temperature 和 top_p 为 0，避免保存的参数无法复现结果
+
+func (provider *Provider) Limits() chat.Limits {
+	return chat.Limits{
+		Temperature: chat.Range{Min: 0, Max: 2, MinExclusive: true},
+		TopP:        chat.Range{Min: 0, Max: 1, MinExclusive: true},
+		MaxTokens:   4096,
+		MaxStop:     4,
+	}
+}
+
+func (provider *Provider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	resp, err := provider.client.cli.CreateChatCompletion(ctx, provider.generateChatCompletionRequest(req))
+	if err != nil {
+		return chat.Response{}, wrapError(err)
+	}
+	
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == nil || resp.Choices[0].Message.Content.StringValue == nil {
+		return chat.Response{}, chat.ErrEmptyResponse
+	}
+	
+	return chat.Response{
+		Model:        resp.Model,
+		Content:      *resp.Choices[0].Message.Content.StringValue,
+		FinishReason: string(resp.Choices[0].FinishReason),
+		Usage: chat.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func (provider *Provider) ChatStream(ctx context.Context, req chat.Request, onDelta func(delta string) error) (chat.Response, error) {
+	body := provider.generateChatCompletionRequest(req)
+	body.StreamOptions = &model.StreamOptions{IncludeUsage: true}
+	
+	response := chat.Response{Model: body.Model}
+	var content strings.Builder
+	
+	stream, err := provider.client.cli.CreateChatCompletionStream(ctx, body)
+	if err != nil {
+		return response, wrapError(err)
+	}
+	defer stream.Close()
+	
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		
+		if err != nil {
+			response.Content = content.String()
+			return response, wrapError(err)
+		}
+		
+		if resp.Usage != nil {
+			response.Usage = chat.Usage{
+				PromptTokens:     resp.Usage.PromptTokens,
+				CompletionTokens: resp.Usage.CompletionTokens,
+				TotalTokens:      resp.Usage.TotalTokens,
+			}
+		}
+		
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		
+		if resp.Choices[0].FinishReason != "" {
+			response.FinishReason = string(resp.Choices[0].FinishReason)
+		}
+		
+		delta := resp.Choices[0].Delta.Content
+		if delta == "" {
+			continue
+		}
+		
+		content.WriteString(delta)
+		err = onDelta(delta)
+		if err != nil {
+			response.Content = content.String()
+			return response, err
+		}
+	}
+	
+	response.Content = content.String()
+	return response, nil
+}
+
+func (provider *Provider) generateChatCompletionRequest(req chat.Request) model.ChatCompletionRequest {
+	body := model.ChatCompletionRequest{
+		Model: req.Model,
+	}
+	
+	if body.Model == "" {
+		body.Model = provider.endpoint
+	}
+	
+	if req.System != "" {
+		body.Messages = append(body.Messages, &model.ChatCompletionMessage{
+			Role:    model.ChatMessageRoleSystem,
+			Content: &model.ChatCompletionMessageContent{StringValue: volcengine.String(req.System)},
+		})
+	}
+	
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, &model.ChatCompletionMessage{
+			Role:    message.Role,
+			Content: &model.ChatCompletionMessageContent{StringValue: volcengine.String(message.Content)},
+		})
+	}
+	
+	// 0 已经在 Limits 中排除
+	if req.Options.Temperature != nil {
+		body.Temperature = float32(*req.Options.Temperature)
+	}
+	if req.Options.TopP != nil {
+		body.TopP = float32(*req.Options.TopP)
+	}
+	body.MaxTokens = req.Options.MaxTokens
+	body.Stop = req.Options.Stop
+	return body
+}
+
+// SDK 的错误转换为 chat.StatusError，用于判断是否可以重试
+
+func wrapError(err error) error {
+	var apiErr *model.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return &chat.StatusError{StatusCode: apiErr.HTTPStatusCode, Body: apiErr.Message}
+	}
+	
+	var requestErr *model.RequestError
+	if errors.As(err, &requestErr) && requestErr.HTTPStatusCode != 0 {
+		return &chat.StatusError{StatusCode: requestErr.HTTPStatusCode, Body: requestErr.Error()}
+	}
+	
+	return err
+}